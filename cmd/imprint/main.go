@@ -1,24 +1,63 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
+	"maps"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"syscall"
 
 	"github.com/kessler-frost/imprint/internal/mcp"
-	"github.com/kessler-frost/imprint/internal/terminal"
+	"github.com/kessler-frost/imprint/terminal"
 )
 
 var Version = "dev"
 
+// envFlag collects repeated --env KEY=VALUE flags.
+type envFlag map[string]string
+
+func (e envFlag) String() string {
+	pairs := make([]string, 0, len(e))
+	for _, key := range slices.Sorted(maps.Keys(e)) {
+		pairs = append(pairs, key+"="+e[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (e envFlag) Set(pair string) error {
+	key, value, ok := strings.Cut(pair, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("want KEY=VALUE, got %q", pair)
+	}
+	e[key] = value
+	return nil
+}
+
 func main() {
-	shell := flag.String("shell", getDefaultShell(), "Shell to run")
+	shell := flag.String("shell", "", "Shell or shell command to run (default: $SHELL)")
 	rows := flag.Int("rows", 24, "Terminal rows")
 	cols := flag.Int("cols", 80, "Terminal columns")
+	cwd := flag.String("cwd", "", "Working directory for the shell (default: current directory)")
+	env := envFlag{}
+	flag.Var(env, "env", "Environment variable for the shell as KEY=VALUE (repeatable)")
+	startupTimeout := flag.Duration("startup-timeout", terminal.DefaultStartupTimeout, "Maximum time to wait for the terminal to become ready")
+	healthInterval := flag.Duration("health-interval", terminal.DefaultHealthCheckInterval, "How often to check browser, page, ttyd and tmux health (0 disables)")
+	autoReconnect := flag.Bool("auto-reconnect", false, "Reconnect the browser page to the tmux session if Chrome, the page or ttyd dies")
+	keyDelay := flag.Duration("key-delay", 0, "Default pause between keys for type_text and send_keystrokes")
+	keyJitter := flag.Duration("key-jitter", 0, "Default extra random pause of up to this much per key")
+	tmuxSocket := flag.String("tmux-socket", terminal.DefaultTmuxSocket, "tmux server socket name, or path if it contains a slash")
+	keyboardLayout := flag.String("keyboard-layout", terminal.DefaultKeyboardLayout, "Keyboard layout key combinations are typed on: "+strings.Join(terminal.KeyboardLayouts(), ", "))
+	noTmux := flag.Bool("no-tmux", false, "Run the command directly under ttyd, without tmux (disables tmux attach)")
 	version := flag.Bool("version", false, "Print version and exit")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] [-- command [args...]]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
 	if *version {
@@ -26,20 +65,45 @@ func main() {
 		os.Exit(0)
 	}
 
-	term, err := terminal.New(*shell, *rows, *cols)
+	opts := []terminal.Option{
+		terminal.WithSize(*rows, *cols),
+		terminal.WithStartupTimeout(*startupTimeout),
+		terminal.WithHealthCheckInterval(*healthInterval),
+		terminal.WithAutoReconnect(*autoReconnect),
+		terminal.WithTmuxSocket(*tmuxSocket),
+		terminal.WithDirect(*noTmux),
+		terminal.WithPace(terminal.Pace{Delay: *keyDelay, Jitter: *keyJitter}),
+		terminal.WithKeyboardLayout(*keyboardLayout),
+	}
+	switch {
+	case *shell != "" && flag.NArg() > 0:
+		log.Fatalf("Give either --shell or a command after --, not both")
+	case flag.NArg() > 0:
+		opts = append(opts, terminal.WithArgs(flag.Args()...))
+	case *shell != "":
+		opts = append(opts, terminal.WithShell(*shell))
+	}
+	if *cwd != "" {
+		opts = append(opts, terminal.WithDir(*cwd))
+	}
+	if len(env) > 0 {
+		opts = append(opts, terminal.WithEnv(env))
+	}
+
+	term, err := terminal.New(opts...)
 	if err != nil {
 		log.Fatalf("Failed to create terminal: %v", err)
 	}
 
-	if err := term.Start(); err != nil {
+	if err := term.Start(context.Background()); err != nil {
 		log.Fatalf("Failed to start terminal: %v", err)
 	}
-	defer term.Close()
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
-	mcpServer := mcp.New(term)
+	mcpServer := mcp.New(term, opts...)
+	defer mcpServer.Close()
 	done := make(chan struct{})
 	go func() {
 		if err := mcpServer.Start(); err != nil {
@@ -54,10 +118,3 @@ func main() {
 	}
 	fmt.Fprintln(os.Stderr, "Shutting down...")
 }
-
-func getDefaultShell() string {
-	if shell := os.Getenv("SHELL"); shell != "" {
-		return shell
-	}
-	return "/bin/bash"
-}
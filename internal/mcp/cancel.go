@@ -0,0 +1,89 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// methodNotificationCancelled is sent by clients to abandon an in-flight request.
+const methodNotificationCancelled = "notifications/cancelled"
+
+// requestIDMetaKey carries the JSON-RPC request id from the before-call hook to
+// the tool middleware, since mcp-go does not pass the id to tool handlers.
+const requestIDMetaKey = "imprint/requestId"
+
+// inflight tracks the cancel functions of running tool calls by request id,
+// so that notifications/cancelled from the client stops the matching call.
+type inflight struct {
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+// tagRequest records the JSON-RPC request id in the call's _meta.
+func (f *inflight) tagRequest(ctx context.Context, id any, request *mcp.CallToolRequest) {
+	if request.Params.Meta == nil {
+		request.Params.Meta = &mcp.Meta{}
+	}
+	if request.Params.Meta.AdditionalFields == nil {
+		request.Params.Meta.AdditionalFields = map[string]any{}
+	}
+	request.Params.Meta.AdditionalFields[requestIDMetaKey] = fmt.Sprint(id)
+}
+
+// middleware gives each tool call a context that is cancelled when the client
+// sends notifications/cancelled for its request id.
+func (f *inflight) middleware(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		if request.Params.Meta != nil {
+			if id, ok := request.Params.Meta.AdditionalFields[requestIDMetaKey].(string); ok {
+				f.mu.Lock()
+				if f.cancels == nil {
+					f.cancels = map[string]context.CancelFunc{}
+				}
+				f.cancels[id] = cancel
+				f.mu.Unlock()
+
+				defer func() {
+					f.mu.Lock()
+					delete(f.cancels, id)
+					f.mu.Unlock()
+				}()
+			}
+		}
+
+		return next(ctx, request)
+	}
+}
+
+// handleCancelled handles notifications/cancelled from the client.
+func (f *inflight) handleCancelled(ctx context.Context, notification mcp.JSONRPCNotification) {
+	id, ok := notification.Params.AdditionalFields["requestId"]
+	if !ok {
+		return
+	}
+
+	f.mu.Lock()
+	cancel, ok := f.cancels[fmt.Sprint(id)]
+	f.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+}
+
+// toolError builds the result for a failed terminal operation. Failures caused
+// by the request being cancelled are reported as such instead of surfacing
+// whatever error the interrupted browser call produced.
+func toolError(ctx context.Context, action string, err error) *mcp.CallToolResult {
+	if ctx.Err() != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Cancelled: %s was interrupted (%v)", action, ctx.Err()))
+	}
+	return mcp.NewToolResultError(fmt.Sprintf("Failed to %s: %v", action, err))
+}
@@ -0,0 +1,62 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// TestCancelledToolCall verifies notifications/cancelled stops the matching
+// in-flight tool call and that the call reports a cancelled result.
+func TestCancelledToolCall(t *testing.T) {
+	s := &Server{}
+	hooks := &server.Hooks{}
+	hooks.AddBeforeCallTool(s.calls.tagRequest)
+	mcpServer := server.NewMCPServer("imprint", "test",
+		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(s.calls.middleware),
+	)
+	mcpServer.AddNotificationHandler(methodNotificationCancelled, s.calls.handleCancelled)
+	mcpServer.AddTool(mcp.NewTool("block"), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		select {
+		case <-ctx.Done():
+			return toolError(ctx, "block", ctx.Err()), nil
+		case <-time.After(5 * time.Second):
+			return mcp.NewToolResultText("not cancelled"), nil
+		}
+	})
+
+	ctx := t.Context()
+	mcpServer.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`))
+
+	done := make(chan mcp.JSONRPCMessage, 1)
+	go func() {
+		done <- mcpServer.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"block","arguments":{}}}`))
+	}()
+
+	// Give the call time to register before cancelling it.
+	time.Sleep(100 * time.Millisecond)
+	mcpServer.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7}}`))
+
+	select {
+	case msg := <-done:
+		response, ok := msg.(mcp.JSONRPCResponse)
+		if !ok {
+			t.Fatalf("expected JSONRPCResponse, got %T", msg)
+		}
+		result, ok := response.Result.(mcp.CallToolResult)
+		if !ok {
+			t.Fatalf("expected CallToolResult, got %T", response.Result)
+		}
+		text := result.Content[0].(mcp.TextContent).Text
+		if !result.IsError || !strings.HasPrefix(text, "Cancelled") {
+			t.Errorf("expected cancelled error result, got %q (isError=%t)", text, result.IsError)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("tool call was not cancelled")
+	}
+}
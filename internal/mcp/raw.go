@@ -0,0 +1,90 @@
+package mcp
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// decodeHex decodes hex input for send_raw such as "1b5b41" or "1b 5b 41".
+// Whitespace between digits is ignored.
+func decodeHex(s string) ([]byte, error) {
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+
+	data, err := hex.DecodeString(digits)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex: %w", err)
+	}
+	return data, nil
+}
+
+// unescape decodes escaped-string input for send_raw. It understands the C
+// escapes \a \b \e \f \n \r \t \v \0 and \\, \xHH for any byte (including
+// invalid UTF-8), and \uHHHH and \UHHHHHHHH for UTF-8 encoded code points.
+// Everything else is sent as its UTF-8 bytes.
+func unescape(s string) ([]byte, error) {
+	var out []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			out = append(out, s[i])
+			continue
+		}
+		if i+1 >= len(s) {
+			return nil, fmt.Errorf("trailing backslash at offset %d", i)
+		}
+
+		escape := s[i+1]
+		switch escape {
+		case 'a':
+			out = append(out, '\a')
+		case 'b':
+			out = append(out, '\b')
+		case 'e', 'E':
+			out = append(out, 0x1b)
+		case 'f':
+			out = append(out, '\f')
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'v':
+			out = append(out, '\v')
+		case '0':
+			out = append(out, 0)
+		case '\\':
+			out = append(out, '\\')
+		case 'x', 'u', 'U':
+			width := map[byte]int{'x': 2, 'u': 4, 'U': 8}[escape]
+			if i+2+width > len(s) {
+				return nil, fmt.Errorf("\\%c at offset %d needs %d hex digits", escape, i, width)
+			}
+			value, err := strconv.ParseUint(s[i+2:i+2+width], 16, 32)
+			if err != nil {
+				return nil, fmt.Errorf("\\%c at offset %d needs %d hex digits, got %q", escape, i, width, s[i+2:i+2+width])
+			}
+			if escape == 'x' {
+				out = append(out, byte(value))
+			} else {
+				if !utf8.ValidRune(rune(value)) {
+					return nil, fmt.Errorf("\\%c at offset %d is not a valid code point: %s", escape, i, s[i+2:i+2+width])
+				}
+				out = utf8.AppendRune(out, rune(value))
+			}
+			i += width
+		default:
+			return nil, fmt.Errorf("unknown escape \\%c at offset %d", escape, i)
+		}
+		i++
+	}
+	return out, nil
+}
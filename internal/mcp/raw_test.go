@@ -0,0 +1,64 @@
+package mcp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeHex(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []byte
+	}{
+		{"1b5b41", []byte("\x1b[A")},
+		{"1b 5b 41", []byte("\x1b[A")},
+		{"00 FF\nc3 28", []byte{0x00, 0xff, 0xc3, 0x28}},
+	}
+	for _, tc := range tests {
+		got, err := decodeHex(tc.in)
+		if err != nil {
+			t.Errorf("decodeHex(%q) failed: %v", tc.in, err)
+			continue
+		}
+		if !bytes.Equal(got, tc.want) {
+			t.Errorf("decodeHex(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+
+	for _, in := range []string{"1b5", "zz", "1b 5"} {
+		if _, err := decodeHex(in); err == nil {
+			t.Errorf("decodeHex(%q): expected error, got nil", in)
+		}
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []byte
+	}{
+		{`plain`, []byte("plain")},
+		{`\e[A`, []byte("\x1b[A")},
+		{`\x1b\x00\xff`, []byte{0x1b, 0x00, 0xff}},
+		{`a\0b`, []byte{'a', 0, 'b'}},
+		{`\r\n\t\\`, []byte("\r\n\t\\")},
+		{`\u00e9\U0001F600`, []byte("é😀")},
+		{`ü`, []byte("ü")},
+	}
+	for _, tc := range tests {
+		got, err := unescape(tc.in)
+		if err != nil {
+			t.Errorf("unescape(%q) failed: %v", tc.in, err)
+			continue
+		}
+		if !bytes.Equal(got, tc.want) {
+			t.Errorf("unescape(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+
+	for _, in := range []string{`\`, `\q`, `\x1`, `\xzz`, `\uD800`} {
+		if _, err := unescape(in); err == nil {
+			t.Errorf("unescape(%q): expected error, got nil", in)
+		}
+	}
+}
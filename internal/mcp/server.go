@@ -4,31 +4,221 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
-	"github.com/kessler-frost/imprint/internal/terminal"
+	"github.com/kessler-frost/imprint/terminal"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
+// DefaultSessionID is the session used by tools called without a session_id.
+const DefaultSessionID = "default"
+
+// maxHoldDuration caps hold_key, which keeps a request open for the whole hold.
+const maxHoldDuration = time.Minute
+
+// maxClicks caps mouse_click at a triple click, the most apps tell apart.
+const maxClicks = 3
+
 // Server is the MCP server for Claude Code integration.
 type Server struct {
-	term *terminal.Terminal
+	mu       sync.RWMutex
+	sessions map[string]*terminal.Terminal
+	defaults []terminal.Option
+	nextID   int
+	calls    inflight
 }
 
-// New creates a new MCP server.
-func New(term *terminal.Terminal) *Server {
+// New creates a new MCP server. The given terminal becomes the default session.
+// The defaults are used for terminals created via create_terminal, with the
+// caller's overrides applied on top.
+func New(term *terminal.Terminal, defaults ...terminal.Option) *Server {
 	return &Server{
-		term: term,
+		sessions: map[string]*terminal.Terminal{DefaultSessionID: term},
+		defaults: defaults,
+	}
+}
+
+// Close terminates every terminal session owned by the server.
+func (s *Server) Close() {
+	s.mu.Lock()
+	sessions := s.sessions
+	s.sessions = map[string]*terminal.Terminal{}
+	s.mu.Unlock()
+
+	for _, term := range sessions {
+		term.Close()
+	}
+}
+
+// withSessionID adds the optional session_id argument shared by all per-terminal tools.
+func withSessionID() mcp.ToolOption {
+	return mcp.WithString("session_id",
+		mcp.Description(fmt.Sprintf("Terminal session to use (default: %q). See create_terminal and list_terminals.", DefaultSessionID)),
+	)
+}
+
+// withPace adds the optional delay_ms and jitter_ms arguments of tools that send input.
+func withPace() mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		mcp.WithNumber("delay_ms",
+			mcp.Description("Pause between keys in milliseconds, to type at a realistic pace (default: the server's --key-delay, normally 0 for no pause)"),
+			mcp.Min(0),
+		)(tool)
+		mcp.WithNumber("jitter_ms",
+			mcp.Description("Up to this many extra random milliseconds per key (default: the server's --key-jitter, normally 0)"),
+			mcp.Min(0),
+		)(tool)
+	}
+}
+
+// requestPace returns the terminal's default pace with the request's delay_ms and
+// jitter_ms arguments applied.
+func requestPace(term *terminal.Terminal, request mcp.CallToolRequest) (terminal.Pace, error) {
+	pace := term.Pace()
+	if delay := request.GetInt("delay_ms", -1); delay >= 0 {
+		pace.Delay = time.Duration(delay) * time.Millisecond
+	} else if delay != -1 {
+		return pace, fmt.Errorf("delay_ms cannot be negative")
+	}
+	if jitter := request.GetInt("jitter_ms", -1); jitter >= 0 {
+		pace.Jitter = time.Duration(jitter) * time.Millisecond
+	} else if jitter != -1 {
+		return pace, fmt.Errorf("jitter_ms cannot be negative")
+	}
+	return pace, nil
+}
+
+// withCommand adds the mutually exclusive args, shell_command and command
+// arguments shared by tools that start a command.
+func withCommand(defaults string) mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		mcp.WithArray("args",
+			mcp.Description("Command to run as an argv array, passed through verbatim without shell interpretation (e.g., ['/usr/bin/app', '--flag', 'two words']). "+defaults),
+			mcp.WithStringItems(),
+		)(tool)
+		mcp.WithString("shell_command",
+			mcp.Description("Command to run as a string (e.g., './my-tui-app --debug'). A bare absolute path like '/bin/zsh' runs as a login shell; anything else runs via sh -c. "+defaults),
+		)(tool)
+		mcp.WithString("command",
+			mcp.Description("Deprecated alias for shell_command."),
+		)(tool)
+	}
+}
+
+// commandOptions converts the args, shell_command and command arguments into
+// a terminal option, along with a description of the command for messages.
+// It returns no option when none of them is given.
+func commandOptions(request mcp.CallToolRequest) ([]terminal.Option, string, error) {
+	args := request.GetStringSlice("args", nil)
+	shellCommand := request.GetString("shell_command", "")
+	if command := request.GetString("command", ""); command != "" {
+		if shellCommand != "" {
+			return nil, "", fmt.Errorf("give either shell_command or command, not both")
+		}
+		shellCommand = command
+	}
+
+	switch {
+	case len(args) > 0 && shellCommand != "":
+		return nil, "", fmt.Errorf("give either args or shell_command, not both")
+	case len(args) > 0:
+		return []terminal.Option{terminal.WithArgs(args...)}, fmt.Sprintf("%q", args), nil
+	case shellCommand != "":
+		return []terminal.Option{terminal.WithShell(shellCommand)}, shellCommand, nil
+	}
+	return nil, "", nil
+}
+
+// withEnvAndCwd adds the optional env and cwd arguments shared by tools that start a command.
+func withEnvAndCwd(defaults string) mcp.ToolOption {
+	return func(tool *mcp.Tool) {
+		mcp.WithObject("env",
+			mcp.Description(fmt.Sprintf("Environment variables for the command as a name-to-value object (e.g., {\"TERM\": \"xterm-256color\", \"NO_COLOR\": \"1\"}). %s variables; pass {} to clear them.", defaults)),
+			mcp.AdditionalProperties(map[string]any{"type": "string"}),
+		)(tool)
+		mcp.WithString("cwd",
+			mcp.Description(fmt.Sprintf("Working directory for the command. %s directory.", defaults)),
+		)(tool)
 	}
 }
 
+// envAndCwdOptions converts the env and cwd arguments into terminal options.
+// Omitted arguments produce no option, leaving the existing setting in place.
+func envAndCwdOptions(request mcp.CallToolRequest) ([]terminal.Option, error) {
+	var opts []terminal.Option
+	args := request.GetArguments()
+
+	if raw, ok := args["env"]; ok && raw != nil {
+		obj, ok := raw.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("env must be an object of name-value pairs")
+		}
+		env := make(map[string]string, len(obj))
+		for key, value := range obj {
+			str, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("env value for %q must be a string", key)
+			}
+			env[key] = str
+		}
+		opts = append(opts, terminal.WithEnv(env))
+	}
+
+	if cwd := request.GetString("cwd", ""); cwd != "" {
+		opts = append(opts, terminal.WithDir(cwd))
+	}
+	return opts, nil
+}
+
+// withKeyboardLayout adds the optional keyboard_layout argument of tools that start a terminal.
+func withKeyboardLayout(defaults string) mcp.ToolOption {
+	return mcp.WithString("keyboard_layout",
+		mcp.Description("Keyboard layout key combinations are typed on, so e.g. ctrl+ö or alt+@ send what that keyboard sends. "+defaults),
+		mcp.Enum(terminal.KeyboardLayouts()...),
+	)
+}
+
+// keyboardLayoutOptions converts the keyboard_layout argument into a terminal option.
+func keyboardLayoutOptions(request mcp.CallToolRequest) []terminal.Option {
+	if layout := request.GetString("keyboard_layout", ""); layout != "" {
+		return []terminal.Option{terminal.WithKeyboardLayout(layout)}
+	}
+	return nil
+}
+
+// session returns the terminal addressed by the request's session_id argument.
+func (s *Server) session(request mcp.CallToolRequest) (*terminal.Terminal, error) {
+	id := request.GetString("session_id", DefaultSessionID)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	term, ok := s.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown terminal session %q", id)
+	}
+	return term, nil
+}
+
 // Start begins the MCP server on stdio.
 func (s *Server) Start() error {
+	hooks := &server.Hooks{}
+	hooks.AddBeforeCallTool(s.calls.tagRequest)
+
 	mcpServer := server.NewMCPServer(
 		"imprint",
 		"1.0.0",
 		server.WithInstructions("AI-controllable terminal via MCP"),
+		server.WithHooks(hooks),
+		server.WithToolHandlerMiddleware(s.calls.middleware),
 	)
+	mcpServer.AddNotificationHandler(methodNotificationCancelled, s.calls.handleCancelled)
 
 	s.registerTools(mcpServer)
 
@@ -41,10 +231,18 @@ func (s *Server) registerTools(mcpServer *server.MCPServer) {
 	sendKeysTool := mcp.NewTool(
 		"send_keystrokes",
 		mcp.WithDescription("Send key presses to the terminal in sequence"),
+		withSessionID(),
 		mcp.WithArray("keys",
-			mcp.Description("Array of keys to send (e.g., ['enter'], ['up', 'up', 'enter'], ['ctrl+c'])"),
-			mcp.Required(),
+			mcp.Description("Array of keys to send (e.g., ['enter'], ['up', 'up', 'enter'], ['ctrl+c'], ['ctrl+shift+p']). Combine any of ctrl, alt, shift and meta with a key using +. See list_keys for every key name. Give either keys or notation."),
+		),
+		mcp.WithString("notation",
+			mcp.Description("Keys as one string in Vim or Emacs notation, instead of keys. Vim mixes literal text with bracketed keys: '<Esc>:wq<CR>', '<C-x><C-s>' (<lt> is a literal <). Emacs separates keys with spaces: 'C-x C-s', 'C-M-f', 'M-x find-file RET'."),
 		),
+		mcp.WithString("notation_style",
+			mcp.Description("Notation of the notation argument (default: vim)"),
+			mcp.Enum(string(terminal.NotationVim), string(terminal.NotationEmacs)),
+		),
+		withPace(),
 	)
 	mcpServer.AddTool(sendKeysTool, s.handleSendKeys)
 
@@ -52,17 +250,180 @@ func (s *Server) registerTools(mcpServer *server.MCPServer) {
 	typeTextTool := mcp.NewTool(
 		"type_text",
 		mcp.WithDescription("Type a string of text into the terminal"),
+		withSessionID(),
 		mcp.WithString("text",
 			mcp.Description("Text to type into the terminal"),
 			mcp.Required(),
 		),
+		withPace(),
 	)
 	mcpServer.AddTool(typeTextTool, s.handleTypeText)
 
+	// Tool: paste_text
+	pasteTool := mcp.NewTool(
+		"paste_text",
+		mcp.WithDescription("Paste text as if from the clipboard. Unlike type_text, the app sees a paste: if it enabled bracketed paste mode, the text arrives wrapped in ESC[200~ and ESC[201~. Reports whether bracketed paste mode was active."),
+		withSessionID(),
+		mcp.WithString("text",
+			mcp.Description("Text to paste"),
+			mcp.Required(),
+		),
+	)
+	mcpServer.AddTool(pasteTool, s.handlePasteText)
+
+	// Tool: compose_text
+	composeTool := mcp.NewTool(
+		"compose_text",
+		mcp.WithDescription("Enter text through an input method (IME), as CJK text is typed: composition events show uncommitted preedit text, then commit the final text. Use it to test preedit rendering and commit handling, which type_text (plain input) skips."),
+		withSessionID(),
+		mcp.WithString("text",
+			mcp.Description("Text to commit (e.g., '日本語'). Omit or leave empty to cancel the composition after the preedit steps."),
+		),
+		mcp.WithArray("preedit",
+			mcp.Description("Successive preedit texts the input method shows while composing (e.g., ['n', 'に', 'にほ', 'にほん', '日本']). Default: text, one character more at a time."),
+			mcp.WithStringItems(),
+		),
+		withPace(),
+	)
+	mcpServer.AddTool(composeTool, s.handleComposeText)
+
+	// Tool: send_raw
+	sendRawTool := mcp.NewTool(
+		"send_raw",
+		mcp.WithDescription("Write exact bytes to the app's PTY, bypassing key handling. Use it for input no key produces: a lone ESC, CSI sequences for unmodelled keys, NUL or invalid UTF-8. "+
+			"type_text and send_keystrokes model a keyboard: text is typed as characters and key names are translated to what xterm.js sends for them, while single non-printable characters are rejected. send_raw sends the bytes unchanged. Give exactly one of hex or text."),
+		withSessionID(),
+		mcp.WithString("hex",
+			mcp.Description("Bytes as hex, whitespace ignored (e.g., '1b5b41' or '1b 5b 41' for ESC [ A)"),
+		),
+		mcp.WithString("text",
+			mcp.Description("Bytes as an escaped string: \\e (ESC), \\xHH (any byte), \\0 (NUL), \\n, \\r, \\t, \\a, \\b, \\f, \\v, \\\\, \\uHHHH and \\UHHHHHHHH (UTF-8); other characters are sent as UTF-8 (e.g., '\\e[1;5A')"),
+		),
+	)
+	mcpServer.AddTool(sendRawTool, s.handleSendRaw)
+
+	// Tool: key_down
+	keyDownTool := mcp.NewTool(
+		"key_down",
+		mcp.WithDescription("Press a key and keep it held until key_up, for apps that act on held keys. Held modifiers apply to every key sent meanwhile, e.g. key_down shift, then send_keystrokes ['right', 'right'], then key_up shift."),
+		withSessionID(),
+		mcp.WithString("key",
+			mcp.Description("Key to press: a key name, character or modifier (e.g., 'up', 'a', 'space', 'shift', 'ctrl'). Keys typed with shift, like 'A', '!' or 'f13', hold shift too until key_up"),
+			mcp.Required(),
+		),
+	)
+	mcpServer.AddTool(keyDownTool, s.handleKeyDown)
+
+	// Tool: key_up
+	keyUpTool := mcp.NewTool(
+		"key_up",
+		mcp.WithDescription("Release a key pressed with key_down"),
+		withSessionID(),
+		mcp.WithString("key",
+			mcp.Description("Key to release, as given to key_down"),
+			mcp.Required(),
+		),
+	)
+	mcpServer.AddTool(keyUpTool, s.handleKeyUp)
+
+	// Tool: hold_key
+	holdKeyTool := mcp.NewTool(
+		"hold_key",
+		mcp.WithDescription("Hold a key down for a duration, then release it. Like a real keyboard, the key auto-repeats once held past the repeat delay, e.g. holding 'right' moves a cursor repeatedly."),
+		withSessionID(),
+		mcp.WithString("key",
+			mcp.Description("Key to hold, optionally with modifiers held along with it (e.g., 'right', 'a', 'shift+down')"),
+			mcp.Required(),
+		),
+		mcp.WithNumber("duration_ms",
+			mcp.Description(fmt.Sprintf("How long to hold the key in milliseconds, up to %d", maxHoldDuration.Milliseconds())),
+			mcp.Required(),
+			mcp.Min(1),
+			mcp.Max(float64(maxHoldDuration.Milliseconds())),
+		),
+		mcp.WithNumber("repeat_rate",
+			mcp.Description(fmt.Sprintf("Auto-repeats per second once repeating; 0 disables auto-repeat (default: %g)", terminal.DefaultKeyRepeat.Rate)),
+			mcp.Min(0),
+		),
+		mcp.WithNumber("repeat_delay_ms",
+			mcp.Description(fmt.Sprintf("How long the key is held before it starts repeating, in milliseconds (default: %d)", terminal.DefaultKeyRepeat.Delay.Milliseconds())),
+			mcp.Min(0),
+		),
+	)
+	mcpServer.AddTool(holdKeyTool, s.handleHoldKey)
+
+	// Tool: list_keys
+	listKeysTool := mcp.NewTool(
+		"list_keys",
+		mcp.WithDescription("List every key name and alias that send_keystrokes, key_down and hold_key accept, by group"),
+	)
+	mcpServer.AddTool(listKeysTool, s.handleListKeys)
+
+	// Tool: set_keyboard_protocol
+	keyboardProtocolTool := mcp.NewTool(
+		"set_keyboard_protocol",
+		mcp.WithDescription("Choose the keyboard encoding send_keystrokes uses. By default (auto) it follows the app: once the app enables the kitty keyboard protocol or xterm modifyOtherKeys, keys are sent as it expects, so ctrl+i and tab differ and keys like ctrl+shift+enter exist. "+
+			"Forcing a protocol sends those keys to apps that read them without enabling the protocol; legacy forces plain xterm keys. get_status shows the protocol in use."),
+		withSessionID(),
+		mcp.WithString("protocol",
+			mcp.Description("auto follows the app; legacy, kitty or modify_other_keys force that encoding"),
+			mcp.Required(),
+			mcp.Enum("auto", "legacy", "kitty", "modify_other_keys"),
+		),
+		mcp.WithNumber("kitty_flags",
+			mcp.Description("Kitty keyboard protocol flags for protocol kitty: 1 disambiguate, 2 report releases, 4 alternate keys, 8 all keys as escapes, 16 associated text (default: 1)"),
+			mcp.Min(1),
+			mcp.Max(31),
+		),
+		mcp.WithNumber("level",
+			mcp.Description("modifyOtherKeys level for protocol modify_other_keys: 1 only keys with no legacy encoding, 2 every modified key (default: 2)"),
+			mcp.Min(1),
+			mcp.Max(2),
+		),
+	)
+	mcpServer.AddTool(keyboardProtocolTool, s.handleSetKeyboardProtocol)
+
+	// Tool: focus_terminal
+	focusTool := mcp.NewTool(
+		"focus_terminal",
+		mcp.WithDescription("Give the terminal focus again after blur_terminal, as when the user switches back to its window. An app with focus reporting on (ESC[?1004h) receives ESC[I."),
+		withSessionID(),
+	)
+	mcpServer.AddTool(focusTool, s.handleFocusTerminal)
+
+	// Tool: blur_terminal
+	blurTool := mcp.NewTool(
+		"blur_terminal",
+		mcp.WithDescription("Take focus away from the terminal, as when the user switches to another window. An app with focus reporting on receives ESC[O, e.g. to auto-save or stop blinking the cursor. Input tools keep working while blurred; get_status shows the focus state."),
+		withSessionID(),
+	)
+	mcpServer.AddTool(blurTool, s.handleBlurTerminal)
+
+	// Tool: get_clipboard
+	getClipboardTool := mcp.NewTool(
+		"get_clipboard",
+		mcp.WithDescription("Get what the app has copied to the clipboard with OSC 52, as TUIs do when copying over SSH or inside tmux: the current clipboard contents and every write the app made, oldest first (up to the last 100), with its selection (c clipboard, p primary)"),
+		withSessionID(),
+	)
+	mcpServer.AddTool(getClipboardTool, s.handleGetClipboard)
+
+	// Tool: set_clipboard
+	setClipboardTool := mcp.NewTool(
+		"set_clipboard",
+		mcp.WithDescription("Put text on the clipboard, as if the user had copied it in another program. The app gets it back when it asks for the clipboard with an OSC 52 paste query (ESC]52;c;?). It is not added to get_clipboard's history of app writes."),
+		withSessionID(),
+		mcp.WithString("text",
+			mcp.Description("Text to put on the clipboard; empty clears it"),
+			mcp.Required(),
+		),
+	)
+	mcpServer.AddTool(setClipboardTool, s.handleSetClipboard)
+
 	// Tool: get_screenshot
 	screenshotTool := mcp.NewTool(
 		"get_screenshot",
 		mcp.WithDescription("Get the current terminal screen as a base64-encoded JPEG image"),
+		withSessionID(),
 		mcp.WithNumber("quality",
 			mcp.Description("JPEG quality 0-100 (default: 70, lower = smaller file)"),
 			mcp.Min(0),
@@ -75,20 +436,33 @@ func (s *Server) registerTools(mcpServer *server.MCPServer) {
 	screenTextTool := mcp.NewTool(
 		"get_screen_text",
 		mcp.WithDescription("Get the current terminal screen content as plain text"),
+		withSessionID(),
 	)
 	mcpServer.AddTool(screenTextTool, s.handleGetScreenText)
 
+	// Tool: get_screen_cells
+	screenCellsTool := mcp.NewTool(
+		"get_screen_cells",
+		mcp.WithDescription("Get the visible screen with colors and attributes, to tell which menu item is highlighted, which text is red or bold, without a screenshot. "+
+			"Each row lists runs of adjacent cells with the same style as col range, text, then anything not default: fg/bg color (palette index 0-255 or #rrggbb), bold, italic, underline, inverse, dim, strikethrough, and wide for double-width characters. "+
+			"Blank cells with the default style are left out, as are rows with nothing else."),
+		withSessionID(),
+	)
+	mcpServer.AddTool(screenCellsTool, s.handleGetScreenCells)
+
 	// Tool: get_status
 	statusTool := mcp.NewTool(
 		"get_status",
-		mcp.WithDescription("Get terminal status information (rows, cols, ready)"),
+		mcp.WithDescription("Get terminal status information (rows, cols, ready, the app's actual PTY size) and the health of the browser, page, ttyd and tmux components"),
+		withSessionID(),
 	)
 	mcpServer.AddTool(statusTool, s.handleGetStatus)
 
 	// Tool: resize_terminal
 	resizeTool := mcp.NewTool(
 		"resize_terminal",
-		mcp.WithDescription("Resize the terminal dimensions"),
+		mcp.WithDescription("Resize the terminal: the app's PTY (it receives SIGWINCH), the tmux window, the xterm grid and the screenshot viewport change together. Fails if the app's PTY does not take the new size."),
+		withSessionID(),
 		mcp.WithNumber("rows",
 			mcp.Description("Number of rows"),
 			mcp.Required(),
@@ -102,13 +476,95 @@ func (s *Server) registerTools(mcpServer *server.MCPServer) {
 	)
 	mcpServer.AddTool(resizeTool, s.handleResize)
 
+	// Tool: mouse_click
+	mouseClickTool := mcp.NewTool(
+		"mouse_click",
+		mcp.WithDescription("Click a terminal cell with the mouse. Row and col are zero-based from the top left, matching the lines of get_screen_text. Apps that enable mouse reporting receive a real mouse report."),
+		withSessionID(),
+		mcp.WithNumber("row",
+			mcp.Description("Zero-based row of the cell to click"),
+			mcp.Required(),
+			mcp.Min(0),
+		),
+		mcp.WithNumber("col",
+			mcp.Description("Zero-based column of the cell to click"),
+			mcp.Required(),
+			mcp.Min(0),
+		),
+		mcp.WithString("button",
+			mcp.Description("Mouse button (default: left)"),
+			mcp.Enum("left", "middle", "right"),
+		),
+		mcp.WithNumber("clicks",
+			mcp.Description(fmt.Sprintf("Number of clicks, e.g. 2 for a double click, up to %d (default: 1)", maxClicks)),
+			mcp.Min(1),
+			mcp.Max(maxClicks),
+		),
+	)
+	mcpServer.AddTool(mouseClickTool, s.handleMouseClick)
+
+	// Tool: mouse_scroll
+	mouseScrollTool := mcp.NewTool(
+		"mouse_scroll",
+		mcp.WithDescription("Scroll the mouse wheel over a terminal cell. Row and col are zero-based from the top left."),
+		withSessionID(),
+		mcp.WithNumber("row",
+			mcp.Description("Zero-based row of the cell to scroll over"),
+			mcp.Required(),
+			mcp.Min(0),
+		),
+		mcp.WithNumber("col",
+			mcp.Description("Zero-based column of the cell to scroll over"),
+			mcp.Required(),
+			mcp.Min(0),
+		),
+		mcp.WithNumber("lines",
+			mcp.Description("Lines to scroll: negative scrolls up, positive scrolls down. Each line is one wheel event."),
+			mcp.Required(),
+		),
+	)
+	mcpServer.AddTool(mouseScrollTool, s.handleMouseScroll)
+
+	// Tool: mouse_drag
+	mouseDragTool := mcp.NewTool(
+		"mouse_drag",
+		mcp.WithDescription("Drag the mouse from one terminal cell to another with a button held, moving through each cell in between. Rows and cols are zero-based from the top left."),
+		withSessionID(),
+		mcp.WithNumber("from_row",
+			mcp.Description("Zero-based row to press the button on"),
+			mcp.Required(),
+			mcp.Min(0),
+		),
+		mcp.WithNumber("from_col",
+			mcp.Description("Zero-based column to press the button on"),
+			mcp.Required(),
+			mcp.Min(0),
+		),
+		mcp.WithNumber("to_row",
+			mcp.Description("Zero-based row to release the button on"),
+			mcp.Required(),
+			mcp.Min(0),
+		),
+		mcp.WithNumber("to_col",
+			mcp.Description("Zero-based column to release the button on"),
+			mcp.Required(),
+			mcp.Min(0),
+		),
+		mcp.WithString("button",
+			mcp.Description("Mouse button (default: left)"),
+			mcp.Enum("left", "middle", "right"),
+		),
+	)
+	mcpServer.AddTool(mouseDragTool, s.handleMouseDrag)
+
 	// Tool: restart_terminal
 	restartTool := mcp.NewTool(
 		"restart_terminal",
 		mcp.WithDescription("Restart the terminal to reflect code changes. Optionally specify a new command to run."),
-		mcp.WithString("command",
-			mcp.Description("Optional new command to run (e.g., './my-tui-app'). If omitted, restarts with the same command."),
-		),
+		withSessionID(),
+		withCommand("If none is given, restarts with the same command."),
+		withEnvAndCwd("If omitted, keeps the current"),
+		withKeyboardLayout("If omitted, keeps the current layout."),
 	)
 	mcpServer.AddTool(restartTool, s.handleRestart)
 
@@ -116,6 +572,7 @@ func (s *Server) registerTools(mcpServer *server.MCPServer) {
 	waitForTextTool := mcp.NewTool(
 		"wait_for_text",
 		mcp.WithDescription("Wait for specified text to appear on screen, polling every 100ms until found or timeout"),
+		withSessionID(),
 		mcp.WithString("text",
 			mcp.Description("Text to wait for (substring match)"),
 			mcp.Required(),
@@ -131,6 +588,7 @@ func (s *Server) registerTools(mcpServer *server.MCPServer) {
 	waitStableTool := mcp.NewTool(
 		"wait_for_stable",
 		mcp.WithDescription("Wait until the screen stops changing for a stable duration or timeout"),
+		withSessionID(),
 		mcp.WithNumber("timeout_ms",
 			mcp.Description("Maximum time to wait in milliseconds (default: 5000)"),
 			mcp.Min(0),
@@ -146,24 +604,90 @@ func (s *Server) registerTools(mcpServer *server.MCPServer) {
 	ttydUrlTool := mcp.NewTool(
 		"get_ttyd_url",
 		mcp.WithDescription("Get the ttyd web terminal URL for viewing the terminal in a browser"),
+		withSessionID(),
 	)
 	mcpServer.AddTool(ttydUrlTool, s.handleGetTtydUrl)
+
+	// Tool: get_process_status
+	processStatusTool := mcp.NewTool(
+		"get_process_status",
+		mcp.WithDescription("Report whether the command in the terminal is still running, its PID, and its exit code or terminating signal and runtime once it has exited. Use this to tell a clean quit apart from a crash."),
+		withSessionID(),
+	)
+	mcpServer.AddTool(processStatusTool, s.handleGetProcessStatus)
+
+	// Tool: create_terminal
+	createTool := mcp.NewTool(
+		"create_terminal",
+		mcp.WithDescription("Create an additional terminal session with its own tmux session, ttyd port and browser page"),
+		mcp.WithString("session_id",
+			mcp.Description("Name for the new session (e.g., 'server', 'client'). Generated if omitted."),
+		),
+		withCommand("If none is given, runs the server's command."),
+		mcp.WithNumber("rows",
+			mcp.Description("Number of rows (default: the server's rows; give together with cols)"),
+			mcp.Min(1),
+		),
+		mcp.WithNumber("cols",
+			mcp.Description("Number of columns (default: the server's cols; give together with rows)"),
+			mcp.Min(1),
+		),
+		withEnvAndCwd("Defaults to the server's"),
+		withKeyboardLayout("Defaults to the server's --keyboard-layout."),
+	)
+	mcpServer.AddTool(createTool, s.handleCreateTerminal)
+
+	// Tool: list_terminals
+	listTool := mcp.NewTool(
+		"list_terminals",
+		mcp.WithDescription("List all terminal sessions with their status and ttyd URLs"),
+	)
+	mcpServer.AddTool(listTool, s.handleListTerminals)
+
+	// Tool: close_terminal
+	closeTool := mcp.NewTool(
+		"close_terminal",
+		mcp.WithDescription("Close a terminal session and release its tmux session, ttyd port and browser"),
+		mcp.WithString("session_id",
+			mcp.Description("Session to close"),
+			mcp.Required(),
+		),
+	)
+	mcpServer.AddTool(closeTool, s.handleCloseTerminal)
 }
 
 // handleSendKeys handles the send_keystrokes tool call.
 func (s *Server) handleSendKeys(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	keys, err := request.RequireStringSlice("keys")
+	term, err := s.session(request)
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
+	keys := request.GetStringSlice("keys", nil)
+	notation := request.GetString("notation", "")
+	switch {
+	case notation != "" && len(keys) > 0:
+		return mcp.NewToolResultError("give either keys or notation, not both"), nil
+	case notation != "":
+		style := terminal.NotationStyle(request.GetString("notation_style", string(terminal.NotationVim)))
+		keys, err = terminal.ParseKeyNotation(notation, style)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid key notation: %v", err)), nil
+		}
+	}
+
 	if len(keys) == 0 {
 		return mcp.NewToolResultError("keys array must not be empty"), nil
 	}
 
-	err = s.term.SendKeys(keys)
+	pace, err := requestPace(term, request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	err = term.SendKeysPaced(ctx, keys, pace)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to send keys: %v", err)), nil
+		return toolError(ctx, "send keys", err), nil
 	}
 
 	return mcp.NewToolResultText(fmt.Sprintf("%d keys sent successfully", len(keys))), nil
@@ -171,26 +695,358 @@ func (s *Server) handleSendKeys(ctx context.Context, request mcp.CallToolRequest
 
 // handleTypeText handles the type_text tool call.
 func (s *Server) handleTypeText(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
 	text, err := request.RequireString("text")
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	err = s.term.Type(text)
+	pace, err := requestPace(term, request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	err = term.TypePaced(ctx, text, pace)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to type text: %v", err)), nil
+		return toolError(ctx, "type text", err), nil
 	}
 
 	return mcp.NewToolResultText(fmt.Sprintf("Text typed successfully (%d characters)", len(text))), nil
 }
 
+// handlePasteText handles the paste_text tool call.
+func (s *Server) handlePasteText(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	text, err := request.RequireString("text")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	bracketed, err := term.Paste(ctx, text)
+	if err != nil {
+		return toolError(ctx, "paste text", err), nil
+	}
+
+	mode := "bracketed paste mode active"
+	if !bracketed {
+		mode = "bracketed paste mode not active, sent unwrapped"
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Text pasted successfully (%d characters, %s)", len(text), mode)), nil
+}
+
+// handleComposeText handles the compose_text tool call.
+func (s *Server) handleComposeText(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	composition := terminal.Composition{
+		Preedit: request.GetStringSlice("preedit", nil),
+		Text:    request.GetString("text", ""),
+	}
+	pace, err := requestPace(term, request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if err := term.Compose(ctx, composition, pace); err != nil {
+		return toolError(ctx, "compose text", err), nil
+	}
+
+	if composition.Text == "" {
+		return mcp.NewToolResultText(fmt.Sprintf("Composition cancelled after %d preedit updates", len(composition.Preedit))), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Composed and committed %q", composition.Text)), nil
+}
+
+// handleSendRaw handles the send_raw tool call.
+func (s *Server) handleSendRaw(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	hexInput := request.GetString("hex", "")
+	textInput := request.GetString("text", "")
+	if (hexInput == "") == (textInput == "") {
+		return mcp.NewToolResultError("give exactly one of hex or text"), nil
+	}
+
+	var data []byte
+	if hexInput != "" {
+		data, err = decodeHex(hexInput)
+	} else {
+		data, err = unescape(textInput)
+	}
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if err := term.SendRaw(ctx, data); err != nil {
+		return toolError(ctx, "send raw bytes", err), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("%d bytes sent: % x", len(data), data)), nil
+}
+
+// handleKeyDown handles the key_down tool call.
+func (s *Server) handleKeyDown(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	key, err := request.RequireString("key")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if err := term.KeyDown(ctx, key); err != nil {
+		return toolError(ctx, "press key", err), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("%s is down (held: %s)", key, strings.Join(term.HeldKeys(), ", "))), nil
+}
+
+// handleKeyUp handles the key_up tool call.
+func (s *Server) handleKeyUp(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	key, err := request.RequireString("key")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if err := term.KeyUp(ctx, key); err != nil {
+		return toolError(ctx, "release key", err), nil
+	}
+
+	held := "none"
+	if keys := term.HeldKeys(); len(keys) > 0 {
+		held = strings.Join(keys, ", ")
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("%s released (held: %s)", key, held)), nil
+}
+
+// handleHoldKey handles the hold_key tool call.
+func (s *Server) handleHoldKey(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	key, err := request.RequireString("key")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	durationMs, err := request.RequireInt("duration_ms")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	repeat := terminal.DefaultKeyRepeat
+	repeat.Rate = request.GetFloat("repeat_rate", repeat.Rate)
+	if delay := request.GetInt("repeat_delay_ms", -1); delay != -1 {
+		repeat.Delay = time.Duration(delay) * time.Millisecond
+	}
+
+	duration := time.Duration(durationMs) * time.Millisecond
+	if duration > maxHoldDuration {
+		return mcp.NewToolResultError(fmt.Sprintf("duration_ms must be at most %d", maxHoldDuration.Milliseconds())), nil
+	}
+	repeats, err := term.HoldKey(ctx, key, duration, repeat)
+	if err != nil {
+		return toolError(ctx, "hold key", err), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Held %s for %v (%d auto-repeats)", key, duration, repeats)), nil
+}
+
+// handleListKeys handles the list_keys tool call.
+func (s *Server) handleListKeys(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var b strings.Builder
+	for _, group := range terminal.KeyNames() {
+		fmt.Fprintf(&b, "%s", group.Name)
+		if group.Note != "" {
+			fmt.Fprintf(&b, " (%s)", group.Note)
+		}
+		b.WriteString(":")
+		for i, key := range group.Keys {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, " %s", key.Name)
+			if len(key.Aliases) > 0 {
+				fmt.Fprintf(&b, " (%s)", strings.Join(key.Aliases, ", "))
+			}
+		}
+		b.WriteString("\n")
+	}
+	return mcp.NewToolResultText(b.String()), nil
+}
+
+// handleSetKeyboardProtocol handles the set_keyboard_protocol tool call.
+func (s *Server) handleSetKeyboardProtocol(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	protocol, err := request.RequireString("protocol")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var p *terminal.KeyboardProtocol
+	switch protocol {
+	case "auto":
+	case "legacy":
+		p = &terminal.KeyboardProtocol{}
+	case "kitty":
+		p = &terminal.KeyboardProtocol{KittyFlags: request.GetInt("kitty_flags", terminal.KittyDisambiguate)}
+	case "modify_other_keys":
+		p = &terminal.KeyboardProtocol{ModifyOtherKeys: request.GetInt("level", 2)}
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("unknown keyboard protocol %q", protocol)), nil
+	}
+	if err := term.SetKeyboardProtocol(p); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return mcp.NewToolResultText("Keyboard: " + formatKeyboard(ctx, term)), nil
+}
+
+// formatKeyboard describes the keyboard protocol send_keystrokes encodes keys for.
+func formatKeyboard(ctx context.Context, term *terminal.Terminal) string {
+	p, forced, err := term.KeyboardProtocol(ctx)
+	if err != nil {
+		return fmt.Sprintf("unknown (%v)", err)
+	}
+	switch {
+	case forced:
+		return p.String() + ", forced by set_keyboard_protocol"
+	case p != terminal.KeyboardProtocol{}:
+		return p.String() + ", enabled by the app"
+	default:
+		return p.String()
+	}
+}
+
+// handleFocusTerminal handles the focus_terminal tool call.
+func (s *Server) handleFocusTerminal(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if err := term.Focus(ctx); err != nil {
+		return toolError(ctx, "focus terminal", err), nil
+	}
+	return mcp.NewToolResultText("Focus: " + formatFocus(ctx, term)), nil
+}
+
+// handleBlurTerminal handles the blur_terminal tool call.
+func (s *Server) handleBlurTerminal(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if err := term.Blur(ctx); err != nil {
+		return toolError(ctx, "blur terminal", err), nil
+	}
+	return mcp.NewToolResultText("Focus: " + formatFocus(ctx, term)), nil
+}
+
+// formatFocus describes whether the terminal has focus and whether the app is
+// told about focus changes.
+func formatFocus(ctx context.Context, term *terminal.Terminal) string {
+	state := "blurred"
+	if term.Focused() {
+		state = "focused"
+	}
+	reporting, err := term.FocusReporting(ctx)
+	switch {
+	case err != nil:
+		return fmt.Sprintf("%s (focus reporting unknown: %v)", state, err)
+	case reporting:
+		return state + " (the app has focus reporting on)"
+	default:
+		return state + " (the app has focus reporting off)"
+	}
+}
+
+// handleGetClipboard handles the get_clipboard tool call.
+func (s *Server) handleGetClipboard(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	clipboard, err := term.Clipboard(ctx)
+	if err != nil {
+		return toolError(ctx, "get clipboard", err), nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Clipboard: %q\n", clipboard.Text)
+	if len(clipboard.History) == 0 {
+		b.WriteString("History: the app has not copied anything")
+		return mcp.NewToolResultText(b.String()), nil
+	}
+	b.WriteString("History, oldest first:")
+	for i, w := range clipboard.History {
+		selection := w.Selection
+		if selection == "" {
+			selection = "default"
+		}
+		fmt.Fprintf(&b, "\n%d. [%s] %q", i+1, selection, w.Text)
+	}
+	return mcp.NewToolResultText(b.String()), nil
+}
+
+// handleSetClipboard handles the set_clipboard tool call.
+func (s *Server) handleSetClipboard(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	text, err := request.RequireString("text")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if err := term.SetClipboard(ctx, text); err != nil {
+		return toolError(ctx, "set clipboard", err), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Clipboard set (%d characters)", len(text))), nil
+}
+
 // handleGetScreenshot handles the get_screenshot tool call.
 func (s *Server) handleGetScreenshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
 	quality := request.GetInt("quality", 70)
 
-	jpegData, err := s.term.Screenshot(quality)
+	jpegData, err := term.Screenshot(ctx, quality)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to capture screenshot: %v", err)), nil
+		return toolError(ctx, "capture screenshot", err), nil
 	}
 
 	encoded := base64.StdEncoding.EncodeToString(jpegData)
@@ -199,24 +1055,131 @@ func (s *Server) handleGetScreenshot(ctx context.Context, request mcp.CallToolRe
 
 // handleGetScreenText handles the get_screen_text tool call.
 func (s *Server) handleGetScreenText(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	text, err := s.term.GetText()
+	term, err := s.session(request)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to get screen text: %v", err)), nil
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	text, err := term.GetText(ctx)
+	if err != nil {
+		return toolError(ctx, "get screen text", err), nil
 	}
 
 	return mcp.NewToolResultText(text), nil
 }
 
+// handleGetScreenCells handles the get_screen_cells tool call.
+func (s *Server) handleGetScreenCells(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	cells, err := term.GetCells(ctx)
+	if err != nil {
+		return toolError(ctx, "get screen cells", err), nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Screen %dx%d", cells.Rows, cells.Cols)
+	for row, runs := range cells.Lines {
+		header := false
+		for _, run := range runs {
+			if run.CellStyle == (terminal.CellStyle{}) && strings.TrimSpace(run.Text) == "" {
+				continue
+			}
+			if !header {
+				fmt.Fprintf(&b, "\nRow %d:", row)
+				header = true
+			}
+			fmt.Fprintf(&b, "\n  %s", formatCellRun(run))
+		}
+	}
+	return mcp.NewToolResultText(b.String()), nil
+}
+
+// formatCellRun describes a run of cells as its columns, its text and the
+// parts of its style that are not the default.
+func formatCellRun(run terminal.CellRun) string {
+	cols := strconv.Itoa(run.Col)
+	if run.Columns() > 1 {
+		cols += "-" + strconv.Itoa(run.Col+run.Columns()-1)
+	}
+	parts := []string{cols, strconv.Quote(run.Text)}
+	if run.FG.Mode != terminal.ColorDefault {
+		parts = append(parts, "fg="+run.FG.String())
+	}
+	if run.BG.Mode != terminal.ColorDefault {
+		parts = append(parts, "bg="+run.BG.String())
+	}
+	for _, attr := range []struct {
+		on   bool
+		name string
+	}{
+		{run.Bold, "bold"},
+		{run.Italic, "italic"},
+		{run.Underline, "underline"},
+		{run.Inverse, "inverse"},
+		{run.Dim, "dim"},
+		{run.Strikethrough, "strikethrough"},
+		{run.Width == 2, "wide"},
+	} {
+		if attr.on {
+			parts = append(parts, attr.name)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
 // handleGetStatus handles the get_status tool call.
 func (s *Server) handleGetStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	rows, cols, ready := s.term.Status()
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
-	status := fmt.Sprintf("Rows: %d\nCols: %d\nReady: %t", rows, cols, ready)
+	rows, cols, ready := term.Status()
+
+	status := fmt.Sprintf("Rows: %d\nCols: %d\nReady: %t\nAttach: %s", rows, cols, ready, attachCommand(term))
+	if ptyRows, ptyCols, err := term.PTYSize(ctx); err != nil {
+		status += fmt.Sprintf("\nPTY size: unknown (%v)", err)
+	} else {
+		status += fmt.Sprintf("\nPTY size: %dx%d", ptyRows, ptyCols)
+	}
+	status += "\nKeyboard: " + formatKeyboard(ctx, term)
+	status += "\nLayout: " + term.KeyboardLayout()
+	status += "\nFocus: " + formatFocus(ctx, term)
+	status += "\n" + formatHealth(term.Health())
 	return mcp.NewToolResultText(status), nil
 }
 
+// formatHealth renders the supervisor's last component health check.
+func formatHealth(health terminal.Health) string {
+	if health.CheckedAt.IsZero() {
+		return "Health: not checked yet"
+	}
+
+	state := "ok"
+	if !health.OK() {
+		state = "degraded (" + strings.Join(health.Problems(), ", ") + ")"
+	}
+
+	result := fmt.Sprintf("Health: %s\nBrowser: %t\nPage: %t\nTtyd: %t\nTmux: %t\nConnected: %t\nChecked: %s ago\nReconnects: %d",
+		state, health.Browser, health.Page, health.Ttyd, health.Tmux, health.Connected,
+		time.Since(health.CheckedAt).Round(time.Millisecond), health.Reconnects)
+	if health.LastError != "" {
+		result += "\nLast error: " + health.LastError
+	}
+	return result
+}
+
 // handleResize handles the resize_terminal tool call.
 func (s *Server) handleResize(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
 	rows, err := request.RequireInt("rows")
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
@@ -227,21 +1190,118 @@ func (s *Server) handleResize(ctx context.Context, request mcp.CallToolRequest)
 		return mcp.NewToolResultError(err.Error()), nil
 	}
 
-	err = s.term.Resize(rows, cols)
+	err = term.Resize(ctx, rows, cols)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to resize terminal: %v", err)), nil
+		return toolError(ctx, "resize terminal", err), nil
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Terminal resized to %dx%d", rows, cols)), nil
+	return mcp.NewToolResultText(fmt.Sprintf("Terminal resized to %dx%d (PTY size verified)", rows, cols)), nil
+}
+
+// handleMouseClick handles the mouse_click tool call.
+func (s *Server) handleMouseClick(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	row, err := request.RequireInt("row")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	col, err := request.RequireInt("col")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	button := terminal.MouseButton(request.GetString("button", string(terminal.MouseLeft)))
+	clicks := request.GetInt("clicks", 1)
+	if clicks > maxClicks {
+		return mcp.NewToolResultError(fmt.Sprintf("clicks must be at most %d", maxClicks)), nil
+	}
+
+	if err := term.MouseClick(ctx, row, col, button, clicks); err != nil {
+		return toolError(ctx, "click", err), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Clicked %s button %d time(s) at row %d, col %d", button, clicks, row, col)), nil
+}
+
+// handleMouseScroll handles the mouse_scroll tool call.
+func (s *Server) handleMouseScroll(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	row, err := request.RequireInt("row")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	col, err := request.RequireInt("col")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	lines, err := request.RequireInt("lines")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	if err := term.MouseScroll(ctx, row, col, lines); err != nil {
+		return toolError(ctx, "scroll", err), nil
+	}
+
+	direction := "down"
+	if lines < 0 {
+		direction, lines = "up", -lines
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Scrolled %s %d line(s) at row %d, col %d", direction, lines, row, col)), nil
+}
+
+// handleMouseDrag handles the mouse_drag tool call.
+func (s *Server) handleMouseDrag(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	var coords [4]int
+	for i, name := range []string{"from_row", "from_col", "to_row", "to_col"} {
+		coords[i], err = request.RequireInt(name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+	}
+	button := terminal.MouseButton(request.GetString("button", string(terminal.MouseLeft)))
+
+	if err := term.MouseDrag(ctx, coords[0], coords[1], coords[2], coords[3], button); err != nil {
+		return toolError(ctx, "drag", err), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Dragged %s button from row %d, col %d to row %d, col %d",
+		button, coords[0], coords[1], coords[2], coords[3])), nil
 }
 
 // handleRestart handles the restart_terminal tool call.
 func (s *Server) handleRestart(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	command := request.GetString("command", "")
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
 
-	err := s.term.Restart(command)
+	opts, command, err := commandOptions(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	extra, err := envAndCwdOptions(request)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to restart terminal: %v", err)), nil
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	opts = append(opts, extra...)
+	opts = append(opts, keyboardLayoutOptions(request)...)
+
+	err = term.Restart(ctx, opts...)
+	if err != nil {
+		return toolError(ctx, "restart terminal", err), nil
 	}
 
 	msg := "Terminal restarted successfully"
@@ -253,6 +1313,11 @@ func (s *Server) handleRestart(ctx context.Context, request mcp.CallToolRequest)
 
 // handleWaitForText handles the wait_for_text tool call.
 func (s *Server) handleWaitForText(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
 	text, err := request.RequireString("text")
 	if err != nil {
 		return mcp.NewToolResultError(err.Error()), nil
@@ -260,9 +1325,9 @@ func (s *Server) handleWaitForText(ctx context.Context, request mcp.CallToolRequ
 
 	timeoutMs := request.GetInt("timeout_ms", 5000)
 
-	elapsedMs, found, err := s.term.WaitForText(text, timeoutMs)
+	elapsedMs, found, err := term.WaitForText(ctx, text, timeoutMs)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to wait for text: %v", err)), nil
+		return toolError(ctx, "wait for text", err), nil
 	}
 
 	if found {
@@ -273,12 +1338,17 @@ func (s *Server) handleWaitForText(ctx context.Context, request mcp.CallToolRequ
 
 // handleWaitForStable handles the wait_for_stable tool call.
 func (s *Server) handleWaitForStable(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
 	timeoutMs := request.GetInt("timeout_ms", 5000)
 	stableMs := request.GetInt("stable_ms", 500)
 
-	elapsedMs, stable, err := s.term.WaitForStable(timeoutMs, stableMs)
+	elapsedMs, stable, err := term.WaitForStable(ctx, timeoutMs, stableMs)
 	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("Failed to wait for stable: %v", err)), nil
+		return toolError(ctx, "wait for stable", err), nil
 	}
 
 	if stable {
@@ -289,8 +1359,170 @@ func (s *Server) handleWaitForStable(ctx context.Context, request mcp.CallToolRe
 
 // handleGetTtydUrl handles the get_ttyd_url tool call.
 func (s *Server) handleGetTtydUrl(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	url := s.term.GetTtydUrl()
-	session := s.term.GetTmuxSession()
-	result := fmt.Sprintf("Web: %s\nTerminal: tmux attach -t %s", url, session)
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Web: %s\nTerminal: %s", term.GetTtydUrl(), attachCommand(term))), nil
+}
+
+// attachCommand returns the tmux attach command for term, or explains why
+// there is none.
+func attachCommand(term *terminal.Terminal) string {
+	if attach := term.AttachCommand(); attach != "" {
+		return attach
+	}
+	return "tmux attach unavailable (direct mode without tmux; the web URL starts its own copy of the command)"
+}
+
+// handleGetProcessStatus handles the get_process_status tool call.
+func (s *Server) handleGetProcessStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	term, err := s.session(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	status, err := term.ProcessStatus(ctx)
+	if err != nil {
+		return toolError(ctx, "get process status", err), nil
+	}
+
+	var state string
+	switch {
+	case status.Running:
+		state = "running"
+	case status.Signal != 0:
+		state = fmt.Sprintf("killed by signal %d (%s)", status.Signal, syscall.Signal(status.Signal))
+	case status.ExitCode >= 0:
+		state = fmt.Sprintf("exited with code %d", status.ExitCode)
+	default:
+		state = "exited (status not yet available)"
+	}
+
+	result := fmt.Sprintf("State: %s\nRunning: %t\nPID: %d\nRuntime: %s",
+		state, status.Running, status.PID, status.Runtime.Round(time.Millisecond))
 	return mcp.NewToolResultText(result), nil
 }
+
+// handleCreateTerminal handles the create_terminal tool call.
+func (s *Server) handleCreateTerminal(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	s.mu.Lock()
+	id := request.GetString("session_id", "")
+	if id == "" {
+		id = s.generateSessionIDLocked()
+	}
+	_, exists := s.sessions[id]
+	s.mu.Unlock()
+
+	if exists {
+		return mcp.NewToolResultError(fmt.Sprintf("terminal session %q already exists", id)), nil
+	}
+
+	opts := append([]terminal.Option{}, s.defaults...)
+	commandOpts, _, err := commandOptions(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	opts = append(opts, commandOpts...)
+	rows, cols := request.GetInt("rows", 0), request.GetInt("cols", 0)
+	if (rows == 0) != (cols == 0) {
+		return mcp.NewToolResultError("rows and cols must be given together"), nil
+	}
+	if rows != 0 {
+		opts = append(opts, terminal.WithSize(rows, cols))
+	}
+	extra, err := envAndCwdOptions(request)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	opts = append(opts, extra...)
+	opts = append(opts, keyboardLayoutOptions(request)...)
+
+	term, err := terminal.New(opts...)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to create terminal: %v", err)), nil
+	}
+	if err := term.Start(ctx); err != nil {
+		term.Close()
+		return toolError(ctx, "start terminal", err), nil
+	}
+
+	// The session starts outside the lock, so another call may have claimed the id meanwhile.
+	s.mu.Lock()
+	if _, exists := s.sessions[id]; exists {
+		s.mu.Unlock()
+		term.Close()
+		return mcp.NewToolResultError(fmt.Sprintf("terminal session %q already exists", id)), nil
+	}
+	s.sessions[id] = term
+	s.mu.Unlock()
+
+	rows, cols, _ = term.Status()
+	return mcp.NewToolResultText(fmt.Sprintf("Terminal %q created (%dx%d)\nWeb: %s", id, rows, cols, term.GetTtydUrl())), nil
+}
+
+// generateSessionIDLocked returns an unused session name. Caller must hold the lock.
+func (s *Server) generateSessionIDLocked() string {
+	for {
+		s.nextID++
+		id := fmt.Sprintf("term%d", s.nextID)
+		if _, exists := s.sessions[id]; !exists {
+			return id
+		}
+	}
+}
+
+// handleListTerminals handles the list_terminals tool call.
+func (s *Server) handleListTerminals(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Copy the sessions out so a terminal busy with a long operation doesn't
+	// hold up other tools while its status is read
+	s.mu.RLock()
+	ids := make([]string, 0, len(s.sessions))
+	terms := make(map[string]*terminal.Terminal, len(s.sessions))
+	for id, term := range s.sessions {
+		ids = append(ids, id)
+		terms[id] = term
+	}
+	s.mu.RUnlock()
+	sort.Strings(ids)
+
+	lines := make([]string, 0, len(ids))
+	for _, id := range ids {
+		term := terms[id]
+		rows, cols, ready := term.Status()
+		tmux := term.GetTmuxSession()
+		if tmux == "" {
+			tmux = "none (direct mode)"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %dx%d, ready=%t, web=%s, tmux=%s",
+			id, rows, cols, ready, term.GetTtydUrl(), tmux))
+	}
+
+	if len(lines) == 0 {
+		return mcp.NewToolResultText("No terminal sessions"), nil
+	}
+	return mcp.NewToolResultText(strings.Join(lines, "\n")), nil
+}
+
+// handleCloseTerminal handles the close_terminal tool call.
+func (s *Server) handleCloseTerminal(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := request.RequireString("session_id")
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	s.mu.Lock()
+	term, ok := s.sessions[id]
+	delete(s.sessions, id)
+	s.mu.Unlock()
+
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("unknown terminal session %q", id)), nil
+	}
+
+	if err := term.Close(); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to close terminal: %v", err)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf("Terminal %q closed", id)), nil
+}
@@ -1,624 +0,0 @@
-package terminal
-
-import (
-	"fmt"
-	"net"
-	"os/exec"
-	"strings"
-	"sync"
-	"time"
-	"unicode"
-	"unicode/utf8"
-
-	"github.com/go-rod/rod"
-	"github.com/go-rod/rod/lib/input"
-	"github.com/go-rod/rod/lib/launcher"
-	"github.com/go-rod/rod/lib/proto"
-	"github.com/rivo/uniseg"
-)
-
-// Terminal manages a real terminal session via ttyd and headless Chrome.
-type Terminal struct {
-	mu          sync.RWMutex
-	browser     *rod.Browser
-	page        *rod.Page
-	cmd         *exec.Cmd
-	port        int
-	shell       string
-	rows        int
-	cols        int
-	tmuxSession string // Unique tmux session name for session sharing
-}
-
-// keyMap maps key names to go-rod input.Key constants
-var keyMap = map[string]input.Key{
-	"enter":     input.Enter,
-	"backspace": input.Backspace,
-	"tab":       input.Tab,
-	"escape":    input.Escape,
-	"up":        input.ArrowUp,
-	"down":      input.ArrowDown,
-	"left":      input.ArrowLeft,
-	"right":     input.ArrowRight,
-	"space":     input.Space,
-	"delete":    input.Delete,
-	"insert":    input.Insert,
-	"home":      input.Home,
-	"end":       input.End,
-	"pageup":    input.PageUp,
-	"pagedown":  input.PageDown,
-	"f1":        input.F1,
-	"f2":        input.F2,
-	"f3":        input.F3,
-	"f4":        input.F4,
-	"f5":        input.F5,
-	"f6":        input.F6,
-	"f7":        input.F7,
-	"f8":        input.F8,
-	"f9":        input.F9,
-	"f10":       input.F10,
-	"f11":       input.F11,
-	"f12":       input.F12,
-}
-
-// characterKeyMap maps characters to input.Key constants for modifier combinations.
-// Used by sendCtrlKey, sendAltKey, sendShiftKey which require physical keyboard simulation
-// via go-rod's KeyActions API. Single printable characters without modifiers bypass this
-// map entirely and are sent directly via xterm's term.input() API to support Unicode,
-// emoji, and grapheme clusters that can't be mapped to physical keys.
-var characterKeyMap = map[rune]input.Key{
-	// Letters
-	'a': input.KeyA, 'b': input.KeyB, 'c': input.KeyC, 'd': input.KeyD,
-	'e': input.KeyE, 'f': input.KeyF, 'g': input.KeyG, 'h': input.KeyH,
-	'i': input.KeyI, 'j': input.KeyJ, 'k': input.KeyK, 'l': input.KeyL,
-	'm': input.KeyM, 'n': input.KeyN, 'o': input.KeyO, 'p': input.KeyP,
-	'q': input.KeyQ, 'r': input.KeyR, 's': input.KeyS, 't': input.KeyT,
-	'u': input.KeyU, 'v': input.KeyV, 'w': input.KeyW, 'x': input.KeyX,
-	'y': input.KeyY, 'z': input.KeyZ,
-	// Digits
-	'0': input.Digit0, '1': input.Digit1, '2': input.Digit2, '3': input.Digit3,
-	'4': input.Digit4, '5': input.Digit5, '6': input.Digit6, '7': input.Digit7,
-	'8': input.Digit8, '9': input.Digit9,
-	// Punctuation
-	'/': input.Slash, '\\': input.Backslash, '.': input.Period, ',': input.Comma,
-	';': input.Semicolon, '\'': input.Quote, '[': input.BracketLeft, ']': input.BracketRight,
-	'-': input.Minus, '=': input.Equal, '`': input.Backquote,
-}
-
-// New creates a new Terminal instance.
-func New(shell string, rows, cols int) (*Terminal, error) {
-	port, err := findFreePort()
-	if err != nil {
-		return nil, fmt.Errorf("failed to find free port: %w", err)
-	}
-
-	return &Terminal{
-		port:        port,
-		shell:       shell,
-		rows:        rows,
-		cols:        cols,
-		tmuxSession: fmt.Sprintf("imprint_%d", port),
-	}, nil
-}
-
-// findFreePort returns an available TCP port.
-func findFreePort() (int, error) {
-	listener, err := net.Listen("tcp", "127.0.0.1:0")
-	if err != nil {
-		return 0, err
-	}
-	defer listener.Close()
-	return listener.Addr().(*net.TCPAddr).Port, nil
-}
-
-// Start launches the terminal session.
-func (t *Terminal) Start() error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-	return t.startUnlocked()
-}
-
-// startUnlocked launches the terminal session without acquiring the lock.
-// Caller must hold the lock.
-func (t *Terminal) startUnlocked() error {
-	// Build ttyd command with tmux session for session sharing
-	// The -A flag attaches to existing session or creates new one
-	// The -2 flag forces 256-color mode for consistent colors across terminals
-	args := []string{
-		"--port", fmt.Sprintf("%d", t.port),
-		"--interface", "127.0.0.1",
-		"--writable",
-		"tmux", "-2", "new-session", "-A", "-s", t.tmuxSession,
-	}
-
-	// Check if this is a shell path (like /bin/zsh) or a complex command
-	if strings.HasPrefix(t.shell, "/") && !strings.Contains(t.shell, " ") {
-		// Simple shell path - run as interactive login shell
-		args = append(args, t.shell, "-l", "-i")
-	} else {
-		// Complex command - wrap in sh -c for proper shell syntax handling
-		args = append(args, "sh", "-c", t.shell)
-	}
-
-	t.cmd = exec.Command("ttyd", args...)
-
-	err := t.cmd.Start()
-	if err != nil {
-		return fmt.Errorf("failed to start ttyd: %w", err)
-	}
-
-	// Wait for ttyd to be ready
-	time.Sleep(500 * time.Millisecond)
-
-	// Launch headless browser
-	url := launcher.New().Headless(true).MustLaunch()
-	t.browser = rod.New().ControlURL(url).MustConnect()
-
-	// Navigate to ttyd
-	t.page = t.browser.MustPage(fmt.Sprintf("http://127.0.0.1:%d", t.port))
-
-	// Wait for terminal to initialize
-	t.page.MustWaitStable()
-
-	return nil
-}
-
-// SendKey sends a keystroke to the terminal.
-func (t *Terminal) SendKey(key string) error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	if t.page == nil {
-		return fmt.Errorf("terminal not ready")
-	}
-
-	return t.sendKeyUnlocked(key)
-}
-
-// sendKeyUnlocked sends a keystroke without acquiring the lock.
-// Caller must hold the lock.
-//
-// The function routes input through three paths:
-//  1. Literal control characters (\n, \r, \t, space) → keyboard simulation
-//  2. Single printable graphemes → xterm's term.input() for Unicode/emoji support
-//  3. Named keys and modifier combos → keyboard simulation via keyMap/characterKeyMap
-func (t *Terminal) sendKeyUnlocked(key string) error {
-	if key == "" {
-		return fmt.Errorf("key cannot be empty")
-	}
-
-	rawKey := key
-
-	// Path 1: Literal control character aliases
-	switch rawKey {
-	case "\n", "\r":
-		if err := t.page.Keyboard.Type(input.Enter); err != nil {
-			return fmt.Errorf("failed to send enter: %w", err)
-		}
-		return nil
-	case "\t":
-		if err := t.page.Keyboard.Type(input.Tab); err != nil {
-			return fmt.Errorf("failed to send tab: %w", err)
-		}
-		return nil
-	case " ":
-		if err := t.page.Keyboard.Type(input.Space); err != nil {
-			return fmt.Errorf("failed to send space: %w", err)
-		}
-		return nil
-	}
-
-	if !utf8.ValidString(rawKey) {
-		return fmt.Errorf("invalid utf-8 key: %q", rawKey)
-	}
-
-	// Path 2: Single printable graphemes (Unicode, emoji, combined characters)
-	// These bypass keyboard simulation because they can't be mapped to physical keys.
-	// Using xterm's term.input() API writes directly to the terminal buffer.
-	if isSingleGrapheme(rawKey) {
-		if !isPrintableString(rawKey) {
-			return fmt.Errorf("non-printable key: %q", rawKey)
-		}
-
-		_, err := t.page.Eval(fmt.Sprintf(`() => {
-			const term = window.term;
-			if (!term || typeof term.input !== 'function') {
-				throw new Error("terminal not initialized");
-			}
-			term.input(%q);
-		}`, rawKey))
-		if err != nil {
-			return fmt.Errorf("failed to send key %q: %w", rawKey, err)
-		}
-		return nil
-	}
-
-	// Path 3: Named keys and modifier combinations
-	key = strings.ToLower(rawKey)
-
-	// Handle modifier combinations like "ctrl+c", "alt+f", "shift+tab"
-	parts := strings.Split(key, "+")
-	if len(parts) == 2 {
-		modifier := parts[0]
-		mainKey := parts[1]
-
-		if mainKey == "" {
-			return fmt.Errorf("incomplete modifier combination: %q (missing key after +)", rawKey)
-		}
-
-		switch modifier {
-		case "ctrl":
-			return t.sendCtrlKey(mainKey)
-		case "alt":
-			return t.sendAltKey(mainKey)
-		case "shift":
-			return t.sendShiftKey(mainKey)
-		default:
-			return fmt.Errorf("unknown modifier %q in: %s", modifier, rawKey)
-		}
-	}
-
-	if len(parts) > 2 {
-		return fmt.Errorf("invalid key format (multiple + signs): %s", rawKey)
-	}
-
-	// Single named key press
-	if k, ok := keyMap[key]; ok {
-		if err := t.page.Keyboard.Type(k); err != nil {
-			return fmt.Errorf("failed to send key %q: %w", rawKey, err)
-		}
-		return nil
-	}
-
-	return fmt.Errorf("unknown key: %s", rawKey)
-}
-
-func isPrintableString(s string) bool {
-	for _, r := range s {
-		if !unicode.IsPrint(r) {
-			return false
-		}
-	}
-	return true
-}
-
-func isSingleGrapheme(s string) bool {
-	graphemes := uniseg.NewGraphemes(s)
-	count := 0
-	for graphemes.Next() {
-		count++
-		if count > 1 {
-			return false
-		}
-	}
-	return count == 1
-}
-
-// SendKeys sends multiple keystrokes to the terminal.
-// Fails fast on the first error, returning which key failed.
-func (t *Terminal) SendKeys(keys []string) error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	if t.page == nil {
-		return fmt.Errorf("terminal not ready")
-	}
-
-	for i, key := range keys {
-		if err := t.sendKeyUnlocked(key); err != nil {
-			return fmt.Errorf("key %d (%s): %w", i, key, err)
-		}
-	}
-	return nil
-}
-
-// sendCtrlKey sends a Ctrl+key combination.
-// Uses go-rod's KeyActions API which handles press/release automatically via Do().
-func (t *Terminal) sendCtrlKey(key string) error {
-	targetKey, err := resolveTargetKey(key)
-	if err != nil {
-		return fmt.Errorf("ctrl+%s: %w", key, err)
-	}
-
-	if err := t.page.KeyActions().Press(input.ControlLeft).Type(targetKey).Do(); err != nil {
-		return fmt.Errorf("failed to send ctrl+%s: %w", key, err)
-	}
-	return nil
-}
-
-// sendAltKey sends an Alt+key combination.
-// Uses go-rod's KeyActions API which handles press/release automatically via Do().
-func (t *Terminal) sendAltKey(key string) error {
-	targetKey, err := resolveTargetKey(key)
-	if err != nil {
-		return fmt.Errorf("alt+%s: %w", key, err)
-	}
-
-	if err := t.page.KeyActions().Press(input.AltLeft).Type(targetKey).Do(); err != nil {
-		return fmt.Errorf("failed to send alt+%s: %w", key, err)
-	}
-	return nil
-}
-
-// sendShiftKey sends a Shift+key combination.
-// Uses go-rod's KeyActions API which handles press/release automatically via Do().
-func (t *Terminal) sendShiftKey(key string) error {
-	targetKey, err := resolveTargetKey(key)
-	if err != nil {
-		return fmt.Errorf("shift+%s: %w", key, err)
-	}
-
-	if err := t.page.KeyActions().Press(input.ShiftLeft).Type(targetKey).Do(); err != nil {
-		return fmt.Errorf("failed to send shift+%s: %w", key, err)
-	}
-	return nil
-}
-
-// resolveTargetKey converts a key name to an input.Key constant.
-// Checks keyMap first for named keys, then characterKeyMap for single characters.
-func resolveTargetKey(key string) (input.Key, error) {
-	if k, ok := keyMap[key]; ok {
-		return k, nil
-	}
-	if len(key) == 1 {
-		char := rune(key[0])
-		if k, ok := characterKeyMap[char]; ok {
-			return k, nil
-		}
-	}
-	return 0, fmt.Errorf("unknown key: %s", key)
-}
-
-// Type types a string of characters.
-func (t *Terminal) Type(text string) error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	if t.page == nil {
-		return fmt.Errorf("terminal not ready")
-	}
-
-	// Write directly to xterm.js via term.input() instead of DOM events.
-	//
-	// xterm.js ignores DOM InputEvents when its internal _keyDownSeen flag is set.
-	// Since SendKey() triggers keyboard events that may leave this flag in an
-	// unpredictable state, Type() must bypass the DOM entirely. The term.input()
-	// API writes directly to xterm's input buffer, avoiding this constraint.
-	_, err := t.page.Eval(fmt.Sprintf(`() => {
-		const term = window.term;
-		if (!term || typeof term.input !== 'function') {
-			throw new Error("terminal not initialized");
-		}
-		term.input(%q);
-	}`, text))
-
-	return err
-}
-
-// Screenshot captures the current screen as JPEG with the specified quality (0-100).
-func (t *Terminal) Screenshot(quality int) ([]byte, error) {
-	t.mu.RLock()
-	defer t.mu.RUnlock()
-
-	if t.page == nil {
-		return nil, fmt.Errorf("terminal not ready")
-	}
-
-	return t.page.Screenshot(false, &proto.PageCaptureScreenshot{
-		Format:  proto.PageCaptureScreenshotFormatJpeg,
-		Quality: &quality,
-	})
-}
-
-// GetText returns the current screen as plain text.
-func (t *Terminal) GetText() (string, error) {
-	t.mu.RLock()
-	defer t.mu.RUnlock()
-
-	if t.page == nil {
-		return "", fmt.Errorf("terminal not ready")
-	}
-
-	// Use xterm.js buffer API to get terminal content
-	result, err := t.page.Eval(`() => {
-		const term = window.term;
-		if (!term) return "";
-
-		const buffer = term.buffer.active;
-		const lines = [];
-		for (let i = 0; i < buffer.length; i++) {
-			lines.push(buffer.getLine(i).translateToString().trimEnd());
-		}
-		return lines.join("\n");
-	}`)
-
-	if err != nil {
-		return "", fmt.Errorf("failed to get terminal text: %w", err)
-	}
-
-	return result.Value.String(), nil
-}
-
-// WaitForText polls until the specified text appears on screen or timeout.
-// Returns elapsed time in ms, whether text was found, and any error.
-func (t *Terminal) WaitForText(text string, timeoutMs int) (elapsedMs int, found bool, err error) {
-	pollInterval := 100 * time.Millisecond
-	startTime := time.Now()
-	timeoutDuration := time.Duration(timeoutMs) * time.Millisecond
-
-	for {
-		screenText, err := t.GetText()
-		if err != nil {
-			return int(time.Since(startTime).Milliseconds()), false, fmt.Errorf("failed to get screen text: %w", err)
-		}
-
-		if strings.Contains(screenText, text) {
-			return int(time.Since(startTime).Milliseconds()), true, nil
-		}
-
-		elapsed := time.Since(startTime)
-		if elapsed >= timeoutDuration {
-			return int(elapsed.Milliseconds()), false, nil
-		}
-
-		time.Sleep(pollInterval)
-	}
-}
-
-// WaitForStable polls until the screen stops changing for stableMs duration or timeout.
-// Returns elapsed time in ms, whether stability was achieved, and any error.
-func (t *Terminal) WaitForStable(timeoutMs, stableMs int) (elapsedMs int, stable bool, err error) {
-	pollInterval := 100 * time.Millisecond
-	timeout := time.Duration(timeoutMs) * time.Millisecond
-	stableDuration := time.Duration(stableMs) * time.Millisecond
-
-	startTime := time.Now()
-	var lastText string
-	var lastChangeTime time.Time
-
-	lastText, err = t.GetText()
-	if err != nil {
-		return 0, false, err
-	}
-	lastChangeTime = startTime
-
-	ticker := time.NewTicker(pollInterval)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			currentText, err := t.GetText()
-			if err != nil {
-				elapsed := int(time.Since(startTime).Milliseconds())
-				return elapsed, false, err
-			}
-
-			if currentText != lastText {
-				lastText = currentText
-				lastChangeTime = time.Now()
-			}
-
-			timeSinceChange := time.Since(lastChangeTime)
-			if timeSinceChange >= stableDuration {
-				elapsed := int(time.Since(startTime).Milliseconds())
-				return elapsed, true, nil
-			}
-
-			if time.Since(startTime) >= timeout {
-				elapsed := int(time.Since(startTime).Milliseconds())
-				return elapsed, false, nil
-			}
-		}
-	}
-}
-
-// Resize changes the terminal dimensions.
-func (t *Terminal) Resize(rows, cols int) error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	if t.page == nil {
-		return fmt.Errorf("terminal not ready")
-	}
-
-	// Use xterm.js resize API
-	_, err := t.page.Eval(fmt.Sprintf(`() => {
-		const term = window.term;
-		if (term) {
-			term.resize(%d, %d);
-		}
-	}`, cols, rows))
-
-	if err != nil {
-		return fmt.Errorf("failed to resize terminal: %w", err)
-	}
-
-	t.rows = rows
-	t.cols = cols
-
-	return nil
-}
-
-// Close terminates the terminal session.
-func (t *Terminal) Close() error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	// Close browser
-	if t.browser != nil {
-		t.browser.MustClose()
-	}
-
-	// Kill ttyd process
-	if t.cmd != nil && t.cmd.Process != nil {
-		t.cmd.Process.Kill()
-		t.cmd.Wait()
-	}
-
-	// Kill tmux session
-	if t.tmuxSession != "" {
-		exec.Command("tmux", "kill-session", "-t", t.tmuxSession).Run()
-	}
-
-	return nil
-}
-
-// Restart closes and restarts the terminal, optionally with a new command.
-// If command is empty, uses the existing shell command.
-func (t *Terminal) Restart(command string) error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	// Close existing browser and ttyd
-	if t.browser != nil {
-		t.browser.MustClose()
-		t.browser = nil
-	}
-	if t.cmd != nil && t.cmd.Process != nil {
-		t.cmd.Process.Kill()
-		t.cmd.Wait()
-		t.cmd = nil
-	}
-	t.page = nil
-
-	// Kill old tmux session
-	if t.tmuxSession != "" {
-		exec.Command("tmux", "kill-session", "-t", t.tmuxSession).Run()
-	}
-
-	// Update command if provided
-	if command != "" {
-		t.shell = command
-	}
-
-	// Keep the same port - wait for it to be released
-	time.Sleep(100 * time.Millisecond)
-
-	// Generate new tmux session name (use timestamp to ensure uniqueness)
-	t.tmuxSession = fmt.Sprintf("imprint_%d_%d", t.port, time.Now().UnixNano()%100000)
-
-	return t.startUnlocked()
-}
-
-// Status returns terminal status information.
-func (t *Terminal) Status() (rows, cols int, ready bool) {
-	t.mu.RLock()
-	defer t.mu.RUnlock()
-	ready = t.page != nil
-	return t.rows, t.cols, ready
-}
-
-// GetTtydUrl returns the URL to access the terminal via web browser.
-func (t *Terminal) GetTtydUrl() string {
-	t.mu.RLock()
-	defer t.mu.RUnlock()
-	return fmt.Sprintf("http://127.0.0.1:%d", t.port)
-}
-
-// GetTmuxSession returns the tmux session name.
-func (t *Terminal) GetTmuxSession() string {
-	t.mu.RLock()
-	defer t.mu.RUnlock()
-	return t.tmuxSession
-}
@@ -0,0 +1,134 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// ColorMode says how a Color is given.
+type ColorMode int
+
+const (
+	ColorDefault ColorMode = iota // The terminal's default foreground or background
+	ColorPalette                  // An index into the 256-color palette
+	ColorRGB                      // A 24-bit color
+)
+
+// Color is a cell's foreground or background color.
+type Color struct {
+	Mode  ColorMode `json:"mode"`
+	Value int       `json:"value"` // Palette index for ColorPalette, 0xRRGGBB for ColorRGB
+}
+
+// String returns "default", a palette index like "1", or an RGB color like "#ff8000".
+func (c Color) String() string {
+	switch c.Mode {
+	case ColorPalette:
+		return strconv.Itoa(c.Value)
+	case ColorRGB:
+		return fmt.Sprintf("#%06x", c.Value)
+	default:
+		return "default"
+	}
+}
+
+// CellStyle is how a cell is drawn: its colors and attributes. Inverse
+// swaps the colors when drawn; FG and BG are the colors as the app set them.
+type CellStyle struct {
+	FG            Color `json:"fg"`
+	BG            Color `json:"bg"`
+	Bold          bool  `json:"bold"`
+	Italic        bool  `json:"italic"`
+	Underline     bool  `json:"underline"`
+	Inverse       bool  `json:"inverse"`
+	Dim           bool  `json:"dim"`
+	Strikethrough bool  `json:"strikethrough"`
+}
+
+// CellRun is a run of adjacent cells on a row that have the same style and width.
+type CellRun struct {
+	Col   int    `json:"col"`   // Column of the first cell, zero-based
+	Cells int    `json:"cells"` // Number of cells, each one character
+	Width int    `json:"width"` // Columns each cell takes: 1, or 2 for wide characters
+	Text  string `json:"text"`  // The cells' characters; empty cells are spaces
+	CellStyle
+}
+
+// Columns returns the number of columns the run covers.
+func (r CellRun) Columns() int {
+	return r.Cells * r.Width
+}
+
+// ScreenCells is the visible screen as runs of cells, row by row.
+type ScreenCells struct {
+	Rows  int         `json:"rows"`
+	Cols  int         `json:"cols"`
+	Lines [][]CellRun `json:"lines"` // One entry per row, top to bottom
+}
+
+// GetCells returns the visible screen with each cell's character, width,
+// colors and attributes, which GetText leaves out: which menu item is
+// highlighted, which text is red or bold. Adjacent cells with the same style
+// and width are grouped into runs to keep the result small.
+func (t *Terminal) GetCells(ctx context.Context) (ScreenCells, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return ScreenCells{}, err
+	}
+
+	// Runs are built in the page, so only they cross to Go, not every cell
+	result, err := t.page.Context(ctx).Eval(`() => {
+		const term = window.term;
+		if (!term) {
+			throw new Error("terminal not initialized");
+		}
+		const buffer = term.buffer.active;
+		const cell = buffer.getNullCell();
+		const color = (isDefault, isRGB, value) => isDefault ? { mode: 0, value: 0 } : { mode: isRGB ? 2 : 1, value };
+		const lines = [];
+		for (let y = 0; y < term.rows; y++) {
+			const line = buffer.getLine(buffer.viewportY + y);
+			const runs = [];
+			let run = null, key = '';
+			for (let x = 0; line && x < term.cols; x++) {
+				line.getCell(x, cell);
+				const width = cell.getWidth();
+				if (width === 0) continue; // The second column of a wide character
+				const style = {
+					fg: color(cell.isFgDefault(), cell.isFgRGB(), cell.getFgColor()),
+					bg: color(cell.isBgDefault(), cell.isBgRGB(), cell.getBgColor()),
+					bold: !!cell.isBold(),
+					italic: !!cell.isItalic(),
+					underline: !!cell.isUnderline(),
+					inverse: !!cell.isInverse(),
+					dim: !!cell.isDim(),
+					strikethrough: !!cell.isStrikethrough(),
+				};
+				const chars = cell.getChars() || ' ';
+				const cellKey = width + JSON.stringify(style);
+				if (run && cellKey === key) {
+					run.cells++;
+					run.text += chars;
+				} else {
+					run = { col: x, cells: 1, width, text: chars, ...style };
+					key = cellKey;
+					runs.push(run);
+				}
+			}
+			lines.push(runs);
+		}
+		return { rows: term.rows, cols: term.cols, lines };
+	}`)
+	if err != nil {
+		return ScreenCells{}, fmt.Errorf("failed to get terminal cells: %w", err)
+	}
+
+	var cells ScreenCells
+	if err := result.Value.Unmarshal(&cells); err != nil {
+		return ScreenCells{}, fmt.Errorf("failed to get terminal cells: %w", err)
+	}
+	return cells, nil
+}
@@ -0,0 +1,190 @@
+package terminal
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxClipboardHistory bounds how many of the app's clipboard writes are kept.
+const maxClipboardHistory = 100
+
+// ClipboardWrite is text the app copied with OSC 52.
+type ClipboardWrite struct {
+	Selection string `json:"selection"` // Selection parameter, like "c" for the clipboard or "p" for the primary selection; "" if omitted
+	Text      string `json:"text"`      // "" when the app cleared the selection
+}
+
+// Clipboard is the terminal's clipboard as the app sees it through OSC 52.
+type Clipboard struct {
+	Text    string           `json:"text"`    // Current contents, which the app's paste queries get back
+	History []ClipboardWrite `json:"history"` // The app's writes, oldest first
+}
+
+// clipboardState is a terminal's clipboard in tmux mode, kept by the output
+// tap from the app's OSC 52 writes.
+type clipboardState struct {
+	text    string
+	history []ClipboardWrite
+}
+
+// apply records the clipboard write in a sequence from the app's output, or
+// returns the reply to a paste query.
+func (c *clipboardState) apply(seq sequence) (reply []byte) {
+	if seq.kind != ']' {
+		return nil
+	}
+	w, query, ok := parseClipboardSequence(seq.body)
+	switch {
+	case !ok:
+		return nil
+	case query:
+		return clipboardReply(w.Selection, c.text)
+	}
+	c.text = w.Text
+	c.history = append(c.history, w)
+	if len(c.history) > maxClipboardHistory {
+		c.history = c.history[1:]
+	}
+	return nil
+}
+
+// parseClipboardSequence parses an OSC 52 payload, "52;selection;base64",
+// which is a write, or a paste query if the data is "?". Data that is not
+// base64 clears the selection, as in xterm.
+func parseClipboardSequence(body string) (w ClipboardWrite, query, ok bool) {
+	rest, ok := strings.CutPrefix(body, "52;")
+	if !ok {
+		return ClipboardWrite{}, false, false
+	}
+	selection, data, ok := strings.Cut(rest, ";")
+	if !ok {
+		return ClipboardWrite{}, false, false
+	}
+	if data == "?" {
+		return ClipboardWrite{Selection: selection}, true, true
+	}
+	text, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		// Like the browser's atob, accept base64 without its padding
+		text, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(data, "="))
+	}
+	if err != nil {
+		text = nil
+	}
+	return ClipboardWrite{Selection: selection, Text: string(text)}, false, true
+}
+
+// clipboardReply answers a paste query for selection with text, as
+// clipboardHook does in direct mode.
+func clipboardReply(selection, text string) []byte {
+	return []byte("\x1b]52;" + selection + ";" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07")
+}
+
+// clipboardHook follows the app's clipboard in direct mode, where xterm.js
+// ignores OSC 52. It keeps the state in window.imprintClipboard and answers
+// paste queries with the current contents, as the output tap does with tmux.
+const clipboardHook = `(term) => {
+	const state = window.imprintClipboard = { text: '', history: [] };
+	const decode = (data) => {
+		try {
+			return new TextDecoder().decode(Uint8Array.from(atob(data), (c) => c.charCodeAt(0)));
+		} catch (e) {
+			return ''; // Not base64, which clears the selection
+		}
+	};
+	const encode = (text) => {
+		let binary = '';
+		for (const b of new TextEncoder().encode(text)) binary += String.fromCharCode(b);
+		return btoa(binary);
+	};
+	term.parser.registerOscHandler(52, (payload) => {
+		const i = payload.indexOf(';');
+		if (i < 0) return true;
+		const selection = payload.slice(0, i);
+		const data = payload.slice(i + 1);
+		if (data === '?') {
+			term._core.coreService.triggerDataEvent('\x1b]52;' + selection + ';' + encode(state.text) + '\x07');
+			return true;
+		}
+		state.text = decode(data);
+		state.history.push({ selection, text: state.text });
+		if (state.history.length > 100) state.history.shift(); // maxClipboardHistory
+		return true;
+	});
+}`
+
+// Clipboard returns what the app has copied with OSC 52: the current contents
+// and the app's writes, oldest first, up to the last 100.
+func (t *Terminal) Clipboard(ctx context.Context) (Clipboard, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return Clipboard{}, err
+	}
+
+	if !t.direct {
+		if t.tap == nil {
+			return Clipboard{}, nil
+		}
+		return t.tap.clipboardContents(), nil
+	}
+
+	result, err := t.page.Context(ctx).Eval(`() => window.imprintClipboard || { text: '', history: [] }`)
+	if err != nil {
+		return Clipboard{}, fmt.Errorf("failed to read clipboard: %w", err)
+	}
+	var c Clipboard
+	if err := result.Value.Unmarshal(&c); err != nil {
+		return Clipboard{}, fmt.Errorf("failed to read clipboard: %w", err)
+	}
+	return c, nil
+}
+
+// SetClipboard puts text on the clipboard, as if the user had copied it in
+// another program, so the app's paste queries (OSC 52 with ?) get it back;
+// "" clears it. It does not add to the history of the app's writes. Each
+// terminal has its own clipboard, which other terminals' apps do not see.
+func (t *Terminal) SetClipboard(ctx context.Context, text string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return err
+	}
+
+	if !t.direct {
+		if t.tap != nil {
+			t.tap.setClipboard(text)
+		}
+		return nil
+	}
+
+	_, err := t.page.Context(ctx).Eval(`(text) => {
+		if (!window.imprintClipboard) {
+			throw new Error("terminal not initialized");
+		}
+		window.imprintClipboard.text = text;
+	}`, text)
+	if err != nil {
+		return fmt.Errorf("failed to set clipboard: %w", err)
+	}
+	return nil
+}
+
+// clipboardContents returns the app's clipboard as the tap has seen it.
+func (o *outputTap) clipboardContents() Clipboard {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return Clipboard{Text: o.clipboard.text, History: slices.Clone(o.clipboard.history)}
+}
+
+// setClipboard replaces the clipboard's contents.
+func (o *outputTap) setClipboard(text string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.clipboard.text = text
+}
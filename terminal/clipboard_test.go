@@ -0,0 +1,46 @@
+package terminal
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestClipboardState(t *testing.T) {
+	var tap outputTap
+	var parser sequenceParser
+	parser.write([]byte("\x1b]52;c;aGVs"), tap.apply)
+	parser.write([]byte("bG8=\x07\x1b]52;p;!\x1b\\\x1b]52;c;?\x07"), tap.apply)
+	want := []ClipboardWrite{{"c", "hello"}, {"p", ""}}
+	if got := tap.clipboardContents(); got.Text != "" || !slices.Equal(got.History, want) {
+		t.Errorf("clipboard after writes = %+v, want history %+v", got, want)
+	}
+
+	tap.setClipboard("wörld")
+	parser.write([]byte("\x1b]52;c;?\x07"), tap.apply)
+	if len(tap.replies) != 2 || string(tap.replies[0]) != "\x1b]52;c;\x07" || string(tap.replies[1]) != "\x1b]52;c;d8O2cmxk\x07" {
+		t.Errorf("replies to paste queries = %q, want the empty clipboard, then \"wörld\"", tap.replies)
+	}
+}
+
+func TestParseClipboardSequence(t *testing.T) {
+	tests := []struct {
+		body  string
+		want  ClipboardWrite
+		query bool
+		ok    bool
+	}{
+		{"52;c;aGVsbG8=", ClipboardWrite{"c", "hello"}, false, true},
+		{"52;;d8O2cmxk", ClipboardWrite{"", "wörld"}, false, true},
+		{"52;c;aGk", ClipboardWrite{"c", "hi"}, false, true},
+		{"52;p;!!", ClipboardWrite{"p", ""}, false, true},
+		{"52;c;?", ClipboardWrite{"c", ""}, true, true},
+		{"2;title", ClipboardWrite{}, false, false},
+		{"52", ClipboardWrite{}, false, false},
+	}
+	for _, tc := range tests {
+		w, query, ok := parseClipboardSequence(tc.body)
+		if w != tc.want || query != tc.query || ok != tc.ok {
+			t.Errorf("parseClipboardSequence(%q) = %+v, %t, %t; want %+v, %t, %t", tc.body, w, query, ok, tc.want, tc.query, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf16"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/rivo/uniseg"
+)
+
+// Composition is text entered through an input method (IME), as Chinese,
+// Japanese and Korean text is: the input method shows uncommitted preedit text
+// while the user composes, then commits the final text.
+type Composition struct {
+	Preedit []string // Successive preedit texts, like "n", "に", "にほ", "日本"; nil shows Text a grapheme at a time
+	Text    string   // Text to commit; "" cancels the composition instead
+}
+
+// imeProcessKey is the key code browsers report for key presses an input
+// method consumes.
+const imeProcessKey = 229
+
+// Compose enters text through the browser's IME path: a compositionstart
+// event, a compositionupdate for each preedit text and a compositionend that
+// commits Text, each after a key press the input method consumed. This is how
+// xterm.js receives text from an input method, and it exercises the preedit
+// rendering and commit handling that TUIs sometimes get wrong, such as
+// inserting the text twice. Updates are spaced out as pace says.
+func (t *Terminal) Compose(ctx context.Context, c Composition, pace Pace) (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return err
+	}
+
+	preedit := c.Preedit
+	if len(preedit) == 0 {
+		if c.Text == "" {
+			return fmt.Errorf("composition needs preedit text or text to commit")
+		}
+		graphemes := uniseg.NewGraphemes(c.Text)
+		for graphemes.Next() {
+			_, end := graphemes.Positions()
+			preedit = append(preedit, c.Text[:end])
+		}
+	}
+	for i, text := range preedit {
+		if text == "" {
+			return fmt.Errorf("preedit text %d is empty", i)
+		}
+	}
+
+	// A composition left open would swallow later input, so cancel it if
+	// the request is cancelled midway
+	page := t.page.Context(ctx)
+	committed := false
+	defer func() {
+		if !committed {
+			proto.InputImeSetComposition{}.Call(t.page)
+		}
+	}()
+
+	for i, text := range preedit {
+		if i > 0 {
+			if err := pace.wait(ctx); err != nil {
+				return err
+			}
+		}
+		if err := t.imeKeyUnlocked(ctx); err != nil {
+			return fmt.Errorf("failed to update composition: %w", err)
+		}
+		caret := len(utf16.Encode([]rune(text)))
+		err := proto.InputImeSetComposition{Text: text, SelectionStart: caret, SelectionEnd: caret}.Call(page)
+		if err != nil {
+			return fmt.Errorf("failed to update composition: %w", err)
+		}
+	}
+
+	if err := pace.wait(ctx); err != nil {
+		return err
+	}
+	if err := t.imeKeyUnlocked(ctx); err != nil {
+		return fmt.Errorf("failed to end composition: %w", err)
+	}
+	if c.Text == "" {
+		err = proto.InputImeSetComposition{}.Call(page)
+	} else {
+		err = proto.InputInsertText{Text: c.Text}.Call(page)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to end composition: %w", err)
+	}
+	committed = true
+	return nil
+}
+
+// imeKeyUnlocked sends a key press consumed by the input method, which
+// browsers report with key code 229. Caller must hold the lock.
+func (t *Terminal) imeKeyUnlocked(ctx context.Context) error {
+	page := t.page.Context(ctx)
+	event := proto.InputDispatchKeyEvent{
+		Type:                  proto.InputDispatchKeyEventTypeRawKeyDown,
+		Key:                   "Process",
+		WindowsVirtualKeyCode: imeProcessKey,
+		Modifiers:             t.heldModifiers(),
+	}
+	if err := event.Call(page); err != nil {
+		return err
+	}
+	event.Type = proto.InputDispatchKeyEventTypeKeyUp
+	return event.Call(t.page)
+}
@@ -0,0 +1,38 @@
+package terminal_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/kessler-frost/imprint/terminal"
+)
+
+func ExampleNew() {
+	ctx := context.Background()
+
+	term, err := terminal.New(
+		terminal.WithShell("/bin/sh"),
+		terminal.WithSize(30, 120),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := term.Start(ctx); err != nil {
+		log.Fatal(err)
+	}
+	defer term.Close()
+
+	if err := term.Type(ctx, "echo hello"); err != nil {
+		log.Fatal(err)
+	}
+	if err := term.SendKey(ctx, "enter"); err != nil {
+		log.Fatal(err)
+	}
+
+	_, found, err := term.WaitForText(ctx, "hello", 5000)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("found:", found)
+}
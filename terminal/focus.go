@@ -0,0 +1,101 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+)
+
+// Focus gives the terminal focus back after Blur, as when the user returns to
+// its window. An app with focus reporting on (CSI ? 1004 h) receives ESC [ I.
+// Focusing a terminal that has focus does nothing.
+func (t *Terminal) Focus(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return err
+	}
+	if !t.blurred {
+		return nil
+	}
+	if err := t.fireFocusUnlocked(ctx, "focus"); err != nil {
+		return err
+	}
+	t.blurred = false
+	return nil
+}
+
+// Blur takes focus away from the terminal, as when the user switches to
+// another window. An app with focus reporting on receives ESC [ O, and xterm.js
+// draws the cursor as it does for an unfocused terminal. Only xterm.js's focus
+// handling runs: the page keeps its keyboard focus, so input still arrives
+// while blurred, as it would from a tool like tmux send-keys.
+func (t *Terminal) Blur(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return err
+	}
+	if t.blurred {
+		return nil
+	}
+	if err := t.fireFocusUnlocked(ctx, "blur"); err != nil {
+		return err
+	}
+	t.blurred = true
+	return nil
+}
+
+// Focused reports whether the terminal has focus, which it has unless Blur
+// took it away.
+func (t *Terminal) Focused() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return !t.blurred
+}
+
+// fireFocusUnlocked fires a focus or blur event at xterm.js's textarea, which
+// runs its focus handling. Caller must hold the lock.
+func (t *Terminal) fireFocusUnlocked(ctx context.Context, event string) error {
+	_, err := t.page.Context(ctx).Eval(`(type) => {
+		const term = window.term;
+		if (!term || !term.textarea) {
+			throw new Error("terminal not initialized");
+		}
+		term.textarea.dispatchEvent(new FocusEvent(type));
+	}`, event)
+	if err != nil {
+		return fmt.Errorf("failed to %s terminal: %w", event, err)
+	}
+	return nil
+}
+
+// FocusReporting reports whether the app has turned on focus reporting, so
+// that it is told when the terminal gains and loses focus.
+func (t *Terminal) FocusReporting(ctx context.Context) (bool, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return false, err
+	}
+
+	// With tmux, xterm.js's mode is tmux's own, which focus-events keeps on
+	if !t.direct {
+		if t.tap == nil {
+			return false, nil
+		}
+		return t.tap.focusReporting(), nil
+	}
+
+	result, err := t.page.Context(ctx).Eval(`() => {
+		const term = window.term;
+		const modes = term.modes || term._core.coreService.decPrivateModes;
+		return !!(modes.sendFocusMode || modes.sendFocus);
+	}`)
+	if err != nil {
+		return false, fmt.Errorf("failed to read focus reporting mode: %w", err)
+	}
+	return result.Value.Bool(), nil
+}
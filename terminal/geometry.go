@@ -0,0 +1,187 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// fitGuardScript stops ttyd refitting the xterm grid to the window whenever the
+// viewport changes, so the grid keeps exactly the size imprint gives it. The
+// capturing listener on window runs before ttyd's own resize listener.
+const fitGuardScript = `window.addEventListener('resize', (e) => e.stopImmediatePropagation(), true);`
+
+// resizeVerifyTimeout bounds how long Resize waits for the PTY to take the new size.
+const resizeVerifyTimeout = 2 * time.Second
+
+// cellMetrics describes where the xterm grid sits on the page, in CSS pixels.
+type cellMetrics struct {
+	Left        float64 `json:"left"`        // Left edge of the grid
+	Top         float64 `json:"top"`         // Top edge of the grid
+	CellWidth   float64 `json:"cellWidth"`   // Width of one cell
+	CellHeight  float64 `json:"cellHeight"`  // Height of one cell
+	FrameWidth  float64 `json:"frameWidth"`  // Viewport width not taken by the grid (padding, scrollbar)
+	FrameHeight float64 `json:"frameHeight"` // Viewport height not taken by the grid
+}
+
+// measureCellsUnlocked reads the xterm grid's position and cell size from the
+// page. Caller must hold at least the read lock.
+func (t *Terminal) measureCellsUnlocked(ctx context.Context) (cellMetrics, error) {
+	result, err := t.page.Context(ctx).Eval(`() => {
+		const term = window.term;
+		const dims = term._core._renderService.dimensions;
+		const cell = dims.css ? dims.css.cell : { width: dims.actualCellWidth, height: dims.actualCellHeight };
+		const screen = term.element.querySelector('.xterm-screen').getBoundingClientRect();
+		return {
+			left: screen.left,
+			top: screen.top,
+			cellWidth: cell.width,
+			cellHeight: cell.height,
+			frameWidth: window.innerWidth - screen.width,
+			frameHeight: window.innerHeight - screen.height,
+		};
+	}`)
+	if err != nil {
+		return cellMetrics{}, fmt.Errorf("failed to measure terminal cells: %w", err)
+	}
+	var m cellMetrics
+	if err := result.Value.Unmarshal(&m); err != nil || m.CellWidth <= 0 || m.CellHeight <= 0 {
+		return cellMetrics{}, fmt.Errorf("failed to measure terminal cells: %s", result.Value.Raw())
+	}
+	return m, nil
+}
+
+// applyGeometryUnlocked sizes the page viewport to fit a rows x cols xterm
+// grid and resizes the grid to match, which ttyd forwards to the PTY.
+// Caller must hold the lock.
+func (t *Terminal) applyGeometryUnlocked(ctx context.Context, rows, cols int) error {
+	page := t.page.Context(ctx)
+
+	m, err := t.measureCellsUnlocked(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = page.SetViewport(&proto.EmulationSetDeviceMetricsOverride{
+		Width:             int(math.Ceil(float64(cols)*m.CellWidth + max(m.FrameWidth, 0))),
+		Height:            int(math.Ceil(float64(rows)*m.CellHeight + max(m.FrameHeight, 0))),
+		DeviceScaleFactor: 1,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to size viewport: %w", err)
+	}
+
+	result, err := page.Eval(`(cols, rows) => {
+		const term = window.term;
+		term.resize(cols, rows);
+		return [term.rows, term.cols];
+	}`, cols, rows)
+	if err != nil {
+		return fmt.Errorf("failed to resize terminal grid: %w", err)
+	}
+	var grid [2]int
+	if err := result.Value.Unmarshal(&grid); err != nil {
+		return fmt.Errorf("failed to resize terminal grid: %w", err)
+	}
+	if grid[0] != rows || grid[1] != cols {
+		return fmt.Errorf("terminal grid is %dx%d, expected %dx%d", grid[0], grid[1], rows, cols)
+	}
+	return nil
+}
+
+// verifyPTYSizeUnlocked waits briefly for the app's PTY to report rows x cols,
+// since ttyd applies grid changes asynchronously, and returns an error if it
+// does not. Caller must hold the lock.
+func (t *Terminal) verifyPTYSizeUnlocked(ctx context.Context, rows, cols int) error {
+	ctx, cancel := context.WithTimeout(ctx, resizeVerifyTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(readinessPollInterval)
+	defer ticker.Stop()
+
+	for {
+		ptyRows, ptyCols, err := t.ptySizeUnlocked(ctx)
+		if err == nil && ptyRows == rows && ptyCols == cols {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return fmt.Errorf("failed to verify PTY size after resize: %w", err)
+			}
+			return fmt.Errorf("resized to %dx%d, but the app's PTY is %dx%d", rows, cols, ptyRows, ptyCols)
+		case <-ticker.C:
+		}
+	}
+}
+
+// PTYSize returns the size of the app's PTY: the pane size tmux reports or,
+// in direct mode, the size of the command's controlling terminal.
+func (t *Terminal) PTYSize(ctx context.Context) (rows, cols int, err error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.page == nil {
+		return 0, 0, fmt.Errorf("terminal not ready")
+	}
+	return t.ptySizeUnlocked(ctx)
+}
+
+// ptySizeUnlocked returns the size of the app's PTY.
+// Caller must hold at least the read lock.
+func (t *Terminal) ptySizeUnlocked(ctx context.Context) (rows, cols int, err error) {
+	if t.direct {
+		return t.directPTYSize(ctx)
+	}
+
+	out, err := t.tmux(ctx, "display-message", "-p", "-t", t.tmuxSession, "#{pane_height} #{pane_width}").Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to query tmux pane size: %w", err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected tmux pane size: %q", out)
+	}
+	rows, rowsErr := strconv.Atoi(fields[0])
+	cols, colsErr := strconv.Atoi(fields[1])
+	if rowsErr != nil || colsErr != nil {
+		return 0, 0, fmt.Errorf("unexpected tmux pane size: %q", out)
+	}
+	return rows, cols, nil
+}
+
+// directPTYSize reads the size of the command's controlling terminal in direct
+// mode. Caller must hold at least the read lock.
+func (t *Terminal) directPTYSize(ctx context.Context) (rows, cols int, err error) {
+	pid, err := t.directPID(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	out, err := exec.CommandContext(ctx, "ps", "-o", "tty=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to find the command's terminal: %w", err)
+	}
+	tty := strings.TrimSpace(string(out))
+
+	// GNU stty names the device with -F, BSD stty with -f
+	flag := "-F"
+	if runtime.GOOS != "linux" {
+		flag = "-f"
+	}
+	out, err = exec.CommandContext(ctx, "stty", flag, "/dev/"+tty, "size").Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read the size of /dev/%s: %w", tty, err)
+	}
+	if _, err := fmt.Sscan(string(out), &rows, &cols); err != nil {
+		return 0, 0, fmt.Errorf("unexpected stty size: %q", out)
+	}
+	return rows, cols, nil
+}
@@ -0,0 +1,268 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// DefaultHealthCheckInterval is how often the supervisor checks component health.
+const DefaultHealthCheckInterval = 2 * time.Second
+
+// healthProbeTimeout bounds each individual health probe.
+const healthProbeTimeout = 2 * time.Second
+
+// Health reports the liveness of each component behind a Terminal, as last
+// observed by the background supervisor.
+type Health struct {
+	Browser    bool      // Chrome responds over CDP
+	Page       bool      // The ttyd page responds and xterm.js is attached
+	Ttyd       bool      // The ttyd process is running
+	Tmux       bool      // The tmux session exists; always true in direct mode
+	Connected  bool      // The tmux session has an attached client (the page's websocket); always true in direct mode
+	CheckedAt  time.Time // When the last check ran; zero if none has run yet
+	Reconnects int       // Successful automatic reconnects since Start or Restart
+	LastError  string    // Most recent probe or reconnect failure, if any
+}
+
+// OK reports whether every component was alive at the last check.
+func (h Health) OK() bool {
+	return h.Browser && h.Page && h.Ttyd && h.Tmux && h.Connected
+}
+
+// Problems lists the components that were found dead at the last check.
+func (h Health) Problems() []string {
+	var problems []string
+	if !h.Browser {
+		problems = append(problems, "browser not responding")
+	}
+	if !h.Page {
+		problems = append(problems, "page not responding")
+	}
+	if !h.Ttyd {
+		problems = append(problems, "ttyd not running")
+	}
+	if !h.Tmux {
+		problems = append(problems, "tmux session gone")
+	}
+	if !h.Connected {
+		problems = append(problems, "websocket disconnected")
+	}
+	return problems
+}
+
+// Health returns the component health observed by the most recent supervisor check.
+func (t *Terminal) Health() Health {
+	t.healthMu.Lock()
+	defer t.healthMu.Unlock()
+	return t.health
+}
+
+// checkReadyUnlocked returns an error if the terminal cannot accept operations,
+// naming the dead components when the supervisor has found any.
+// Caller must hold the lock.
+func (t *Terminal) checkReadyUnlocked() error {
+	if t.page == nil {
+		return fmt.Errorf("terminal not ready")
+	}
+
+	health := t.Health()
+	if !health.CheckedAt.IsZero() && !health.OK() {
+		return fmt.Errorf("terminal unhealthy: %s", strings.Join(health.Problems(), ", "))
+	}
+	return nil
+}
+
+// startSupervisorUnlocked starts the background health supervisor, if enabled.
+// Caller must hold the lock.
+func (t *Terminal) startSupervisorUnlocked() {
+	t.healthMu.Lock()
+	t.health = Health{}
+	t.healthMu.Unlock()
+
+	if t.healthInterval <= 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	t.stopHealth = stop
+	go t.supervise(stop, t.healthInterval, t.autoReconnect && !t.direct)
+}
+
+// stopSupervisorUnlocked signals the health supervisor to stop.
+// Caller must hold the lock.
+func (t *Terminal) stopSupervisorUnlocked() {
+	if t.stopHealth != nil {
+		close(t.stopHealth)
+		t.stopHealth = nil
+	}
+}
+
+// supervise checks component health every interval until stop is closed and,
+// if reconnect is set, reattaches a fresh page to the surviving tmux session.
+// Its settings are passed in, since Restart replaces the config while an old
+// supervisor may still be finishing a tick. It only ever try-locks mu so it
+// cannot deadlock with Close or Restart; ticks that find the terminal busy are
+// skipped.
+func (t *Terminal) supervise(stop <-chan struct{}, interval time.Duration, reconnect bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		if !t.mu.TryRLock() {
+			continue
+		}
+		health, err := t.probeHealthUnlocked()
+		t.mu.RUnlock()
+
+		t.healthMu.Lock()
+		health.Reconnects = t.health.Reconnects
+		health.LastError = t.health.LastError
+		if err != nil {
+			health.LastError = err.Error()
+		}
+		t.health = health
+		t.healthMu.Unlock()
+
+		// Without a surviving tmux session there is nothing to reconnect to
+		if health.OK() || !health.Tmux || !reconnect {
+			continue
+		}
+
+		if !t.mu.TryLock() {
+			continue
+		}
+		select {
+		case <-stop:
+			t.mu.Unlock()
+			return
+		default:
+		}
+		err = t.reconnectUnlocked(health)
+		if err == nil {
+			// Re-check right away so operations are not blocked by stale health until the next tick
+			health, err = t.probeHealthUnlocked()
+		}
+		t.mu.Unlock()
+
+		t.healthMu.Lock()
+		if err != nil {
+			t.health.LastError = fmt.Sprintf("reconnect failed: %v", err)
+		} else {
+			health.Reconnects = t.health.Reconnects + 1
+			health.LastError = t.health.LastError
+			t.health = health
+		}
+		t.healthMu.Unlock()
+	}
+}
+
+// probeHealthUnlocked checks each component once. The returned error describes
+// the first failed probe. Caller must hold at least the read lock.
+func (t *Terminal) probeHealthUnlocked() (Health, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), healthProbeTimeout)
+	defer cancel()
+
+	health := Health{CheckedAt: time.Now()}
+	var firstErr error
+	fail := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	select {
+	case <-t.exited:
+		fail(fmt.Errorf("ttyd exited: %v", t.cmd.ProcessState))
+	default:
+		health.Ttyd = true
+	}
+
+	if _, err := (proto.BrowserGetVersion{}).Call(t.browser.Context(ctx)); err != nil {
+		fail(fmt.Errorf("browser: %w", err))
+	} else {
+		health.Browser = true
+	}
+
+	if health.Browser {
+		result, err := t.page.Context(ctx).Eval(`() => {
+			const term = window.term;
+			return !!(term && term.element && term.element.isConnected);
+		}`)
+		switch {
+		case err != nil:
+			fail(fmt.Errorf("page: %w", err))
+		case !result.Value.Bool():
+			fail(fmt.Errorf("page: xterm.js is not attached"))
+		default:
+			health.Page = true
+		}
+	}
+
+	if t.direct {
+		// No tmux session to check. ttyd closes the page's connection when the
+		// command exits, but that is the app finishing rather than a dead
+		// component: its final screen can still be read, and ProcessStatus
+		// reports the exit
+		health.Tmux = true
+		health.Connected = true
+		return health, firstErr
+	}
+
+	out, err := t.tmux(ctx, "display-message", "-p", "-t", t.tmuxSession, "#{session_attached}").Output()
+	if err != nil {
+		fail(fmt.Errorf("tmux: session %s not found", t.tmuxSession))
+	} else {
+		health.Tmux = true
+		attached, _ := strconv.Atoi(strings.TrimSpace(string(out)))
+		health.Connected = attached > 0
+		if !health.Connected {
+			fail(fmt.Errorf("tmux: no client attached to session %s", t.tmuxSession))
+		}
+	}
+
+	// A failing tap leaves the keyboard protocol, focus reporting and
+	// clipboard stale without killing any component, so it is only reported
+	if t.tap != nil {
+		if err := t.tap.failure(); err != nil {
+			fail(fmt.Errorf("output tap: %w", err))
+		}
+	}
+
+	return health, firstErr
+}
+
+// reconnectUnlocked restores the dead components around a surviving tmux
+// session. ttyd runs attach-session on the existing session, so the app keeps
+// running and only the viewer side is rebuilt. Caller must hold the lock.
+func (t *Terminal) reconnectUnlocked(health Health) error {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), t.startupTimeout, errStartupTimeout)
+	defer cancel()
+
+	if !health.Ttyd {
+		if err := t.startTtydUnlocked(ctx); err != nil {
+			return err
+		}
+	}
+
+	if !health.Browser {
+		t.browser.Close()
+		if err := t.launchBrowserUnlocked(); err != nil {
+			return err
+		}
+	} else {
+		t.page.Close()
+	}
+
+	return t.openPageUnlocked(ctx)
+}
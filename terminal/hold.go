@@ -0,0 +1,290 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod/lib/input"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// KeyRepeat configures auto-repeat for HoldKey, like a keyboard's repeat settings.
+type KeyRepeat struct {
+	Delay time.Duration // How long the key is held before it starts repeating
+	Rate  float64       // Repeats per second once repeating; zero disables auto-repeat
+}
+
+// DefaultKeyRepeat matches common desktop keyboard settings.
+var DefaultKeyRepeat = KeyRepeat{Delay: 500 * time.Millisecond, Rate: 30}
+
+// KeyDown presses key and keeps it held until KeyUp, for apps that act on held
+// keys. key is a single key name, character or modifier, like "up", "a" or
+// "shift". Held modifiers apply to every key sent while they are down. Keys
+// typed with shift, like A, ! or F13, press shift too unless it is already
+// held, and KeyUp releases it with them.
+func (t *Terminal) KeyDown(ctx context.Context, key string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return err
+	}
+	k, shift, err := resolveHeldKey(key)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(t.heldKeys, k) {
+		return fmt.Errorf("failed to press %s: key is already held", key)
+	}
+
+	pressedShift := false
+	if shift && !slices.Contains(t.heldKeys, input.ShiftLeft) {
+		if err := t.keyDownUnlocked(ctx, input.ShiftLeft); err != nil {
+			return fmt.Errorf("failed to press shift for %s: %w", key, err)
+		}
+		pressedShift = true
+	}
+	if err := t.keyDownUnlocked(ctx, k); err != nil {
+		if pressedShift {
+			t.keyUpUnlocked(input.ShiftLeft)
+		}
+		return fmt.Errorf("failed to press %s: %w", key, err)
+	}
+	if pressedShift || (shift && len(t.shiftedFor) > 0) {
+		t.shiftedFor = append(t.shiftedFor, k)
+	}
+	return nil
+}
+
+// KeyUp releases a key pressed by KeyDown, and the shift KeyDown pressed for
+// it once no other held key needs it.
+func (t *Terminal) KeyUp(ctx context.Context, key string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return err
+	}
+	k, _, err := resolveHeldKey(key)
+	if err != nil {
+		return err
+	}
+	if err := t.keyUpUnlocked(k); err != nil {
+		return fmt.Errorf("failed to release %s: %w", key, err)
+	}
+
+	i := slices.Index(t.shiftedFor, k)
+	if i < 0 {
+		return nil
+	}
+	t.shiftedFor = slices.Delete(t.shiftedFor, i, i+1)
+	if len(t.shiftedFor) == 0 && slices.Contains(t.heldKeys, input.ShiftLeft) {
+		if err := t.keyUpUnlocked(input.ShiftLeft); err != nil {
+			return fmt.Errorf("failed to release shift for %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// HeldKeys returns the names of the keys pressed by KeyDown and not yet released.
+func (t *Terminal) HeldKeys() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	names := make([]string, len(t.heldKeys))
+	for i, k := range t.heldKeys {
+		names[i] = k.Info().Key
+	}
+	return names
+}
+
+// HoldKey holds key down for duration and then releases it. key may include
+// modifiers, like "shift+right", which are held for the whole time; modifiers
+// already held by KeyDown stay held afterwards. Once the key has been held for
+// repeat.Delay, it auto-repeats repeat.Rate times per second, as a keyboard
+// does. It returns the number of repeats sent.
+//
+// The terminal is only locked to press, repeat and release the key, so
+// screenshots and reads go on while it is held.
+func (t *Terminal) HoldKey(ctx context.Context, key string, duration time.Duration, repeat KeyRepeat) (repeats int, err error) {
+	if duration <= 0 {
+		return 0, fmt.Errorf("hold duration must be positive, got %v", duration)
+	}
+	if repeat.Delay < 0 || repeat.Rate < 0 {
+		return 0, fmt.Errorf("key repeat delay and rate cannot be negative")
+	}
+
+	target, shift, err := resolveHeldKey(key)
+	var modifiers []input.Key
+	if shift {
+		modifiers = []input.Key{input.ShiftLeft}
+	}
+	if err != nil && len(key) > 1 && strings.Contains(key, "+") {
+		target, modifiers, err = parseKeyCombo(key)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	deadline := time.Now().Add(duration)
+	pressed, err := t.pressHeldKeys(ctx, key, append(modifiers, target))
+	defer func() {
+		if releaseErr := t.releaseHeldKeys(pressed); err == nil && releaseErr != nil {
+			err = fmt.Errorf("failed to release %s: %w", key, releaseErr)
+		}
+	}()
+	if err != nil {
+		return 0, err
+	}
+
+	if repeat.Rate <= 0 || duration <= repeat.Delay {
+		return 0, sleep(ctx, time.Until(deadline))
+	}
+
+	if err := sleep(ctx, repeat.Delay); err != nil {
+		return 0, err
+	}
+	interval := time.Duration(float64(time.Second) / repeat.Rate)
+	for time.Until(deadline) > 0 {
+		if err := t.repeatHeldKey(ctx, target); err != nil {
+			return repeats, fmt.Errorf("failed to repeat %s: %w", key, err)
+		}
+		repeats++
+		if err := sleep(ctx, min(interval, time.Until(deadline))); err != nil {
+			return repeats, err
+		}
+	}
+	return repeats, nil
+}
+
+// pressHeldKeys presses keys for HoldKey, skipping modifiers KeyDown already
+// holds, and returns the keys it pressed, even if it fails partway.
+func (t *Terminal) pressHeldKeys(ctx context.Context, key string, keys []input.Key) ([]input.Key, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return nil, err
+	}
+	var pressed []input.Key
+	for _, k := range keys {
+		if k.Modifier() != 0 && slices.Contains(t.heldKeys, k) {
+			continue
+		}
+		if err := t.keyDownUnlocked(ctx, k); err != nil {
+			return pressed, fmt.Errorf("failed to hold %s: %w", key, err)
+		}
+		pressed = append(pressed, k)
+	}
+	return pressed, nil
+}
+
+// repeatHeldKey sends an auto-repeat of a key HoldKey holds.
+func (t *Terminal) repeatHeldKey(ctx context.Context, k input.Key) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return err
+	}
+	if !slices.Contains(t.heldKeys, k) {
+		return fmt.Errorf("key was released during the hold")
+	}
+	event := t.encodeKey(k, proto.InputDispatchKeyEventTypeKeyDown, t.heldModifiers())
+	event.AutoRepeat = true
+	return event.Call(t.page.Context(ctx))
+}
+
+// releaseHeldKeys releases the keys HoldKey pressed, in reverse order. Keys
+// released meanwhile, by KeyUp or by a Restart, are skipped, as is everything
+// once the terminal is closed.
+func (t *Terminal) releaseHeldKeys(pressed []input.Key) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.page == nil {
+		return nil
+	}
+	var err error
+	for i := len(pressed) - 1; i >= 0; i-- {
+		if !slices.Contains(t.heldKeys, pressed[i]) {
+			continue
+		}
+		if releaseErr := t.keyUpUnlocked(pressed[i]); err == nil {
+			err = releaseErr
+		}
+	}
+	return err
+}
+
+// resolveHeldKey resolves a single key name, character or modifier for
+// KeyDown, KeyUp and HoldKey, reporting whether the name implies shift, as
+// resolveComboKey does.
+func resolveHeldKey(key string) (input.Key, bool, error) {
+	if key == "" {
+		return 0, false, fmt.Errorf("key cannot be empty")
+	}
+	name := strings.ToLower(key)
+	if canonical, ok := modifierAliases[name]; ok {
+		name = canonical
+	}
+	if k, ok := modifierKeys[name]; ok {
+		return k, false, nil
+	}
+	if len(name) > 1 && strings.Contains(name, "+") {
+		return 0, false, fmt.Errorf("%s: press each key of a combination separately", key)
+	}
+	return resolveComboKey(key)
+}
+
+// keyDownUnlocked presses k and records it as held. Caller must hold the lock.
+func (t *Terminal) keyDownUnlocked(ctx context.Context, k input.Key) error {
+	if slices.Contains(t.heldKeys, k) {
+		return fmt.Errorf("key is already held")
+	}
+	modifiers := t.heldModifiers() | k.Modifier()
+	if err := t.encodeKey(k, proto.InputDispatchKeyEventTypeKeyDown, modifiers).Call(t.page.Context(ctx)); err != nil {
+		return err
+	}
+	t.heldKeys = append(t.heldKeys, k)
+	return nil
+}
+
+// keyUpUnlocked releases a held key. Like the releases in pressKey, it is not
+// bound to a request context, so no key is left held down in the browser when
+// a request is cancelled. Caller must hold the lock.
+func (t *Terminal) keyUpUnlocked(k input.Key) error {
+	i := slices.Index(t.heldKeys, k)
+	if i < 0 {
+		return fmt.Errorf("key is not held")
+	}
+	t.heldKeys = slices.Delete(t.heldKeys, i, i+1)
+	if k == input.ShiftLeft {
+		t.shiftedFor = nil
+	}
+	return t.encodeKey(k, proto.InputDispatchKeyEventTypeKeyUp, t.heldModifiers()).Call(t.page)
+}
+
+// heldModifiers returns the CDP modifier bits of the keys held by KeyDown.
+// Caller must hold the lock.
+func (t *Terminal) heldModifiers() int {
+	modifiers := 0
+	for _, k := range t.heldKeys {
+		modifiers |= k.Modifier()
+	}
+	return modifiers
+}
+
+// encodeKey encodes a key event for k, sending its shifted form (like A for a)
+// while shift is held, as a real keyboard does.
+func (t *Terminal) encodeKey(k input.Key, eventType proto.InputDispatchKeyEventType, modifiers int) *proto.InputDispatchKeyEvent {
+	if modifiers&input.ShiftLeft.Modifier() != 0 {
+		if shifted, ok := k.Shift(); ok {
+			k = shifted
+		}
+	}
+	return k.Encode(eventType, modifiers)
+}
@@ -0,0 +1,41 @@
+package terminal
+
+import (
+	"testing"
+
+	"github.com/go-rod/rod/lib/input"
+)
+
+func TestResolveHeldKey(t *testing.T) {
+	tests := []struct {
+		key   string
+		want  input.Key
+		shift bool
+	}{
+		{"a", input.KeyA, false},
+		{"A", input.KeyA, true},
+		{"!", input.Digit1, true},
+		{"f1", input.F1, false},
+		{"f13", input.F1, true},
+		{"F24", input.F12, true},
+		{"up", input.ArrowUp, false},
+		{"Shift", input.ShiftLeft, false},
+		{"control", input.ControlLeft, false},
+	}
+	for _, tc := range tests {
+		got, shift, err := resolveHeldKey(tc.key)
+		if err != nil {
+			t.Errorf("resolveHeldKey(%q) failed: %v", tc.key, err)
+			continue
+		}
+		if got != tc.want || shift != tc.shift {
+			t.Errorf("resolveHeldKey(%q) = %q, %t; want %q, %t", tc.key, got.Info().Key, shift, tc.want.Info().Key, tc.shift)
+		}
+	}
+
+	for _, key := range []string{"", "ctrl+a", "nokey"} {
+		if _, _, err := resolveHeldKey(key); err == nil {
+			t.Errorf("resolveHeldKey(%q): expected error, got nil", key)
+		}
+	}
+}
@@ -0,0 +1,471 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-rod/rod/lib/input"
+)
+
+// Kitty keyboard protocol flags, as an app enables them with CSI > flags u.
+const (
+	KittyDisambiguate     = 1  // Report modified and ambiguous keys, like ctrl+i or escape, as CSI u
+	KittyEventTypes       = 2  // Report key releases too
+	KittyAlternateKeys    = 4  // Report the shifted key alongside the base key
+	KittyAllKeysAsEscapes = 8  // Report every key, plain text included, as an escape sequence
+	KittyAssociatedText   = 16 // Report the text a key types alongside it
+)
+
+// kittyStackSize is how many entries the kitty keyboard flag stack keeps;
+// pushing more drops the oldest, as in kitty.
+const kittyStackSize = 16
+
+// KeyboardProtocol is the keyboard encoding an app has asked the terminal for.
+// The zero value is the legacy encoding every terminal supports.
+type KeyboardProtocol struct {
+	KittyFlags      int `json:"kittyFlags"`      // Kitty keyboard protocol flags; takes precedence over ModifyOtherKeys
+	ModifyOtherKeys int `json:"modifyOtherKeys"` // xterm modifyOtherKeys level, 0 to 2
+}
+
+// String describes the protocol, like "kitty (flags 1)" or "modifyOtherKeys 2".
+func (p KeyboardProtocol) String() string {
+	switch {
+	case p.KittyFlags != 0:
+		return fmt.Sprintf("kitty (flags %d)", p.KittyFlags)
+	case p.ModifyOtherKeys != 0:
+		return fmt.Sprintf("modifyOtherKeys %d", p.ModifyOtherKeys)
+	default:
+		return "legacy"
+	}
+}
+
+func (p KeyboardProtocol) validate() error {
+	if p.KittyFlags < 0 || p.KittyFlags > 31 {
+		return fmt.Errorf("kitty keyboard flags must be between 0 and 31, got %d", p.KittyFlags)
+	}
+	if p.ModifyOtherKeys < 0 || p.ModifyOtherKeys > 2 {
+		return fmt.Errorf("modifyOtherKeys level must be between 0 and 2, got %d", p.ModifyOtherKeys)
+	}
+	return nil
+}
+
+// keyboardHook follows the app's keyboard protocol in direct mode, where the
+// app talks to xterm.js, which supports neither protocol. It keeps the state
+// in window.imprintKeyboard and answers the app's kitty query (CSI ? u) as a
+// kitty-capable terminal would.
+const keyboardHook = `(term) => {
+	const kitty = [];
+	const state = window.imprintKeyboard = { kitty, modifyOtherKeys: 0 };
+	const flags = () => kitty.length ? kitty[kitty.length - 1] : 0;
+	const param = (params, i, def) => {
+		const v = Array.isArray(params[i]) ? params[i][0] : params[i];
+		return v > 0 ? v : def;
+	};
+	const csi = (prefix, final, handler) => term.parser.registerCsiHandler({ prefix, final }, (params) => {
+		handler(params);
+		return true;
+	});
+	csi('>', 'u', (p) => {
+		kitty.push(param(p, 0, 0));
+		if (kitty.length > 16) kitty.shift(); // kittyStackSize
+	});
+	csi('<', 'u', (p) => { kitty.splice(Math.max(kitty.length - param(p, 0, 1), 0)); });
+	csi('=', 'u', (p) => {
+		const mode = param(p, 1, 1);
+		let next = param(p, 0, 0);
+		if (mode === 2) next = flags() | next;
+		if (mode === 3) next = flags() & ~next;
+		if (kitty.length) kitty[kitty.length - 1] = next; else kitty.push(next);
+	});
+	csi('?', 'u', () => { term._core.coreService.triggerDataEvent('\x1b[?' + flags() + 'u'); });
+	csi('>', 'm', (p) => {
+		if (param(p, 0, 0) === 4) state.modifyOtherKeys = param(p, 1, 0);
+		else if (p.length === 0 || (p.length === 1 && !param(p, 0, 0))) state.modifyOtherKeys = 0;
+	});
+	// A full reset (RIS) clears both; xterm.js still does its own reset
+	term.parser.registerEscHandler({ final: 'c' }, () => {
+		kitty.length = 0;
+		state.modifyOtherKeys = 0;
+		return false;
+	});
+}`
+
+// KeyboardProtocol returns the keyboard protocol SendKey encodes keys for, and
+// whether it was forced by SetKeyboardProtocol rather than enabled by the app.
+func (t *Terminal) KeyboardProtocol(ctx context.Context) (KeyboardProtocol, bool, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return KeyboardProtocol{}, false, err
+	}
+	if t.keyboardOverride != nil {
+		return *t.keyboardOverride, true, nil
+	}
+	p, err := t.appKeyboardUnlocked(ctx)
+	return p, false, err
+}
+
+// SetKeyboardProtocol makes SendKey encode keys for p whatever the app has
+// enabled, to send keys that exist only under a protocol (like ctrl+shift+enter)
+// to an app that reads them without asking, or to force legacy keys with the
+// zero KeyboardProtocol. nil goes back to following the app.
+func (t *Terminal) SetKeyboardProtocol(p *KeyboardProtocol) error {
+	if p != nil {
+		if err := p.validate(); err != nil {
+			return err
+		}
+		p = &KeyboardProtocol{KittyFlags: p.KittyFlags, ModifyOtherKeys: p.ModifyOtherKeys}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.keyboardOverride = p
+	return nil
+}
+
+// keyboardUnlocked returns the keyboard protocol in effect.
+// Caller must hold at least the read lock.
+func (t *Terminal) keyboardUnlocked(ctx context.Context) (KeyboardProtocol, error) {
+	if t.keyboardOverride != nil {
+		return *t.keyboardOverride, nil
+	}
+	return t.appKeyboardUnlocked(ctx)
+}
+
+// appKeyboardUnlocked returns the keyboard protocol the app has enabled: from
+// the output tap with tmux, or from keyboardHook in direct mode.
+// Caller must hold at least the read lock.
+func (t *Terminal) appKeyboardUnlocked(ctx context.Context) (KeyboardProtocol, error) {
+	if !t.direct {
+		if t.tap == nil {
+			return KeyboardProtocol{}, nil
+		}
+		return t.tap.keyboard(), nil
+	}
+
+	result, err := t.page.Context(ctx).Eval(`() => {
+		const state = window.imprintKeyboard;
+		if (!state) return { kittyFlags: 0, modifyOtherKeys: 0 };
+		return {
+			kittyFlags: state.kitty.length ? state.kitty[state.kitty.length - 1] : 0,
+			modifyOtherKeys: state.modifyOtherKeys,
+		};
+	}`)
+	if err != nil {
+		return KeyboardProtocol{}, fmt.Errorf("failed to read keyboard protocol: %w", err)
+	}
+	var p KeyboardProtocol
+	if err := result.Value.Unmarshal(&p); err != nil {
+		return KeyboardProtocol{}, fmt.Errorf("failed to read keyboard protocol: %w", err)
+	}
+	return p, nil
+}
+
+// Modifier bits of the kitty and xterm key encodings, which add one to their sum.
+const (
+	shiftBit = 1
+	altBit   = 2
+	ctrlBit  = 4
+	metaBit  = 8
+)
+
+// functionalKey is how a non-text key is encoded: CSI number ~ for number
+// keys, or CSI 1 ; mods final for letter keys.
+type functionalKey struct {
+	number int
+	final  byte
+}
+
+// functionalKeys are the keys both protocols leave in their legacy CSI form,
+// with modifiers added.
+var functionalKeys = map[input.Key]functionalKey{
+	input.ArrowUp:    {1, 'A'},
+	input.ArrowDown:  {1, 'B'},
+	input.ArrowRight: {1, 'C'},
+	input.ArrowLeft:  {1, 'D'},
+	input.Home:       {1, 'H'},
+	input.End:        {1, 'F'},
+	input.F1:         {1, 'P'},
+	input.F2:         {1, 'Q'},
+	input.F3:         {13, '~'},
+	input.F4:         {1, 'S'},
+	input.Insert:     {2, '~'},
+	input.Delete:     {3, '~'},
+	input.PageUp:     {5, '~'},
+	input.PageDown:   {6, '~'},
+	input.F5:         {15, '~'},
+	input.F6:         {17, '~'},
+	input.F7:         {18, '~'},
+	input.F8:         {19, '~'},
+	input.F9:         {20, '~'},
+	input.F10:        {21, '~'},
+	input.F11:        {23, '~'},
+	input.F12:        {24, '~'},
+}
+
+// controlKeyCodes are the key codes of the keys that type a control character.
+var controlKeyCodes = map[input.Key]int{
+	input.Enter:     13,
+	input.Tab:       9,
+	input.Backspace: 127,
+	input.Escape:    27,
+}
+
+// keypadKeyCodes are the kitty key codes of the keypad keys.
+var keypadKeyCodes = map[input.Key]int{
+	keypad0: 57399, keypad1: 57400, keypad2: 57401, keypad3: 57402, keypad4: 57403,
+	keypad5: 57404, keypad6: 57405, keypad7: 57406, keypad8: 57407, keypad9: 57408,
+	keypadDecimal:        57409,
+	input.NumpadDivide:   57410,
+	input.NumpadMultiply: 57411,
+	input.NumpadSubtract: 57412,
+	input.NumpadAdd:      57413,
+	input.NumpadEnter:    57414,
+}
+
+// textKeys are the keys in characterKeyMap, which type a character.
+var textKeys = func() map[input.Key]bool {
+	m := make(map[input.Key]bool)
+	for _, k := range characterKeyMap {
+		m[k] = true
+	}
+	return m
+}()
+
+// protocolKey is a key to encode for a keyboard protocol.
+type protocolKey struct {
+	base input.Key // The key as typed without shift
+	mods int       // Modifier bits
+}
+
+// parseProtocolKey resolves a SendKey key name to the key and modifiers a
+// protocol encodes, or ok false for keys no protocol covers, like emoji.
+func parseProtocolKey(key string) (k protocolKey, ok bool) {
+	switch key {
+	case "\n", "\r":
+		key = "enter"
+	case "\t":
+		key = "tab"
+	case " ":
+		key = "space"
+	}
+
+	names, name, err := splitKeyCombo(key)
+	if err != nil {
+		return protocolKey{}, false
+	}
+	base, shift, err := resolveComboKey(name)
+	if err != nil {
+		return protocolKey{}, false
+	}
+	if slices.Contains(names, "ctrl") && len(name) == 1 && unicode.IsLetter(rune(name[0])) {
+		shift = false // Case does not matter with ctrl, as in parseKeyCombo
+	}
+
+	k.base = base
+	if shift || slices.Contains(names, "shift") {
+		k.mods |= shiftBit
+	}
+	if slices.Contains(names, "alt") {
+		k.mods |= altBit
+	}
+	if slices.Contains(names, "ctrl") {
+		k.mods |= ctrlBit
+	}
+	if slices.Contains(names, "meta") {
+		k.mods |= metaBit
+	}
+	return k, true
+}
+
+// char returns the character a text key types, shifted if shift is held, and
+// whether it is a text key at all.
+func (k protocolKey) char() (rune, bool) {
+	if _, ok := keypadKeyCodes[k.base]; ok {
+		return 0, false
+	}
+	if k.base != input.Space && !textKeys[k.base] {
+		return 0, false
+	}
+	if k.mods&shiftBit != 0 {
+		if shifted, ok := k.base.Shift(); ok {
+			return rune(shifted), true
+		}
+	}
+	return rune(k.base), true
+}
+
+// encodeProtocolKey returns the bytes an app that enabled p expects for key,
+// or ok false if p leaves the key to its legacy encoding, which SendKey's
+// usual paths produce.
+func encodeProtocolKey(key string, p KeyboardProtocol) (data []byte, ok bool) {
+	if p.KittyFlags == 0 && p.ModifyOtherKeys == 0 {
+		return nil, false
+	}
+	k, ok := parseProtocolKey(key)
+	if !ok {
+		return nil, false
+	}
+	if p.KittyFlags != 0 {
+		return encodeKittyKey(k, p.KittyFlags)
+	}
+	return encodeModifyOtherKeys(k, p.ModifyOtherKeys)
+}
+
+// encodeKittyKey encodes k for the kitty keyboard protocol with the given flags.
+func encodeKittyKey(k protocolKey, flags int) ([]byte, bool) {
+	allKeys := flags&KittyAllKeysAsEscapes != 0
+	mods := k.mods + 1
+
+	// Keys with a legacy CSI form keep it, so they are left to xterm.js
+	// unless the app asked for releases or for every key as an escape
+	if fk, ok := functionalKeys[k.base]; ok {
+		if !allKeys && flags&KittyEventTypes == 0 {
+			return nil, false
+		}
+		seq := kittyFunctionalKey(fk, mods, 1)
+		if flags&KittyEventTypes != 0 {
+			seq += kittyFunctionalKey(fk, mods, 3)
+		}
+		return []byte(seq), true
+	}
+
+	var code, alternate int
+	var text rune
+	plain := k.mods&^shiftBit == 0 // Types text, at most shifted
+	if c, ok := controlKeyCodes[k.base]; ok {
+		code = c
+		if !allKeys && k.mods == 0 && k.base != input.Escape {
+			return nil, false
+		}
+	} else if c, ok := keypadKeyCodes[k.base]; ok {
+		// Disambiguation gives the keypad its own codes even unmodified, so
+		// keypad enter and digits differ from the main keys
+		code = c
+	} else if c, ok := k.char(); ok {
+		if !allKeys && plain {
+			return nil, false
+		}
+		code = int(unicode.ToLower(rune(k.base)))
+		if flags&KittyAlternateKeys != 0 && k.mods&shiftBit != 0 && c != rune(code) {
+			alternate = int(c)
+		}
+		if flags&KittyAssociatedText != 0 && plain {
+			text = c
+		}
+	} else {
+		return nil, false
+	}
+
+	seq := kittyTextKey(code, alternate, mods, 1, text)
+	if flags&KittyEventTypes != 0 {
+		seq += kittyTextKey(code, alternate, mods, 3, 0)
+	}
+	return []byte(seq), true
+}
+
+// kittyTextKey formats CSI code[:alternate][;mods[:event]][;text] u, where
+// event 1 is a press and 3 a release.
+func kittyTextKey(code, alternate, mods, event int, text rune) string {
+	var b strings.Builder
+	b.WriteString("\x1b[")
+	b.WriteString(strconv.Itoa(code))
+	if alternate != 0 {
+		b.WriteString(":" + strconv.Itoa(alternate))
+	}
+	if mods > 1 || event != 1 || text != 0 {
+		b.WriteString(";")
+		if mods > 1 || event != 1 {
+			b.WriteString(strconv.Itoa(mods))
+		}
+		if event != 1 {
+			b.WriteString(":" + strconv.Itoa(event))
+		}
+	}
+	if text != 0 {
+		b.WriteString(";" + strconv.Itoa(int(text)))
+	}
+	b.WriteString("u")
+	return b.String()
+}
+
+// kittyFunctionalKey formats a functional key like CSI 1 ; mods A or
+// CSI 5 ; mods ~, with the event type after the modifiers for releases.
+func kittyFunctionalKey(fk functionalKey, mods, event int) string {
+	params := ""
+	if mods > 1 || event != 1 {
+		params = ";" + strconv.Itoa(mods)
+		if event != 1 {
+			params += ":" + strconv.Itoa(event)
+		}
+	}
+	if fk.final != '~' && params == "" {
+		return "\x1b[" + string(fk.final)
+	}
+	return "\x1b[" + strconv.Itoa(fk.number) + params + string(fk.final)
+}
+
+// encodeModifyOtherKeys encodes k as xterm does with modifyOtherKeys at level,
+// as CSI 27 ; mods ; code ~. Level 1 covers only the modified keys that have
+// no legacy encoding, like ctrl+enter or ctrl+1; level 2 covers every
+// modified key that types text or a control character, like ctrl+i. Other
+// keys keep their legacy encoding.
+func encodeModifyOtherKeys(k protocolKey, level int) ([]byte, bool) {
+	if k.mods == 0 {
+		return nil, false
+	}
+	var code int
+	text, isText := k.char()
+	if c, ok := controlKeyCodes[k.base]; ok {
+		code = c
+		if k.base == input.Tab && k.mods == shiftBit {
+			return nil, false // shift+tab is CSI Z
+		}
+	} else if isText {
+		code = int(text)
+		if k.mods == shiftBit && k.base != input.Space {
+			return nil, false // Typed as the shifted character
+		}
+	} else {
+		return nil, false
+	}
+
+	if level == 1 && hasLegacyEncoding(k) {
+		return nil, false
+	}
+	return []byte(fmt.Sprintf("\x1b[27;%d;%d~", k.mods+1, code)), true
+}
+
+// hasLegacyEncoding reports whether a modified key has an encoding outside
+// modifyOtherKeys: alt adds an escape prefix to any such key, shift types the
+// shifted character, and ctrl makes a control character from a letter or from
+// one of controlCharacters.
+func hasLegacyEncoding(k protocolKey) bool {
+	switch rest := k.mods &^ altBit; rest {
+	case 0:
+		return true
+	case shiftBit:
+		_, isText := k.char()
+		return isText && k.base != input.Space
+	case ctrlBit:
+		if k.base == input.Space {
+			return true
+		}
+		c, isText := k.char()
+		if !isText {
+			return false
+		}
+		if unicode.IsLetter(c) {
+			return true
+		}
+		_, ok := controlCharacters[string(c)]
+		return ok
+	default:
+		return false
+	}
+}
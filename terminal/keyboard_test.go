@@ -0,0 +1,45 @@
+package terminal
+
+import "testing"
+
+func TestEncodeProtocolKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		protocol KeyboardProtocol
+		want     string // "" if the key keeps its legacy encoding
+	}{
+		{"ctrl+i", KeyboardProtocol{KittyFlags: KittyDisambiguate}, "\x1b[105;5u"},
+		{"tab", KeyboardProtocol{KittyFlags: KittyDisambiguate}, ""},
+		{"ctrl+shift+enter", KeyboardProtocol{KittyFlags: KittyDisambiguate}, "\x1b[13;6u"},
+		{"escape", KeyboardProtocol{KittyFlags: KittyDisambiguate}, "\x1b[27u"},
+		{"a", KeyboardProtocol{KittyFlags: KittyDisambiguate}, ""},
+		{"A", KeyboardProtocol{KittyFlags: KittyAllKeysAsEscapes | KittyAlternateKeys}, "\x1b[97:65;2u"},
+		{"ctrl+up", KeyboardProtocol{KittyFlags: KittyDisambiguate | KittyEventTypes}, "\x1b[1;5A\x1b[1;5:3A"},
+		{"ctrl+kp1", KeyboardProtocol{KittyFlags: KittyDisambiguate}, "\x1b[57400;5u"},
+		{"kp1", KeyboardProtocol{KittyFlags: KittyDisambiguate}, "\x1b[57400u"},
+		{"kpenter", KeyboardProtocol{KittyFlags: KittyDisambiguate}, "\x1b[57414u"},
+		{"shift+kp0", KeyboardProtocol{KittyFlags: KittyDisambiguate}, "\x1b[57399;2u"},
+		{"kpenter", KeyboardProtocol{ModifyOtherKeys: 2}, ""},
+		{"ctrl+i", KeyboardProtocol{ModifyOtherKeys: 1}, ""},
+		{"ctrl+1", KeyboardProtocol{ModifyOtherKeys: 1}, "\x1b[27;5;49~"},
+		{"ctrl+i", KeyboardProtocol{ModifyOtherKeys: 2}, "\x1b[27;5;105~"},
+		{"ctrl+shift+a", KeyboardProtocol{ModifyOtherKeys: 2}, "\x1b[27;6;65~"},
+		{"shift+tab", KeyboardProtocol{ModifyOtherKeys: 2}, ""},
+		{"ctrl+i", KeyboardProtocol{}, ""},
+	}
+	for _, tc := range tests {
+		got, ok := encodeProtocolKey(tc.key, tc.protocol)
+		if string(got) != tc.want || ok != (tc.want != "") {
+			t.Errorf("encodeProtocolKey(%q, %v) = %q, %t; want %q", tc.key, tc.protocol, got, ok, tc.want)
+		}
+	}
+}
+
+func TestKeyboardProtocolValidate(t *testing.T) {
+	if err := (&KeyboardProtocol{KittyFlags: 64}).validate(); err == nil {
+		t.Error("validate() with kitty flags 64: expected error, got nil")
+	}
+	if err := (&KeyboardProtocol{ModifyOtherKeys: 3}).validate(); err == nil {
+		t.Error("validate() with modifyOtherKeys 3: expected error, got nil")
+	}
+}
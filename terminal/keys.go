@@ -0,0 +1,383 @@
+package terminal
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-rod/rod/lib/input"
+)
+
+// Keypad digits and decimal point with Num Lock on. go-rod's keypad keys carry
+// the key codes they have with Num Lock off (Home, the arrows, Delete...),
+// which xterm.js turns into cursor keys rather than digits.
+var (
+	keypad0       = input.AddKey("0", "", "Numpad0", 96, 3)
+	keypad1       = input.AddKey("1", "", "Numpad1", 97, 3)
+	keypad2       = input.AddKey("2", "", "Numpad2", 98, 3)
+	keypad3       = input.AddKey("3", "", "Numpad3", 99, 3)
+	keypad4       = input.AddKey("4", "", "Numpad4", 100, 3)
+	keypad5       = input.AddKey("5", "", "Numpad5", 101, 3)
+	keypad6       = input.AddKey("6", "", "Numpad6", 102, 3)
+	keypad7       = input.AddKey("7", "", "Numpad7", 103, 3)
+	keypad8       = input.AddKey("8", "", "Numpad8", 104, 3)
+	keypad9       = input.AddKey("9", "", "Numpad9", 105, 3)
+	keypadDecimal = input.AddKey(".", "", "NumpadDecimal", 110, 3)
+)
+
+// keyMap maps key names to go-rod input.Key constants
+var keyMap = map[string]input.Key{
+	"enter":      input.Enter,
+	"backspace":  input.Backspace,
+	"tab":        input.Tab,
+	"escape":     input.Escape,
+	"up":         input.ArrowUp,
+	"down":       input.ArrowDown,
+	"left":       input.ArrowLeft,
+	"right":      input.ArrowRight,
+	"space":      input.Space,
+	"delete":     input.Delete,
+	"insert":     input.Insert,
+	"home":       input.Home,
+	"end":        input.End,
+	"pageup":     input.PageUp,
+	"pagedown":   input.PageDown,
+	"f1":         input.F1,
+	"f2":         input.F2,
+	"f3":         input.F3,
+	"f4":         input.F4,
+	"f5":         input.F5,
+	"f6":         input.F6,
+	"f7":         input.F7,
+	"f8":         input.F8,
+	"f9":         input.F9,
+	"f10":        input.F10,
+	"f11":        input.F11,
+	"f12":        input.F12,
+	"kp0":        keypad0,
+	"kp1":        keypad1,
+	"kp2":        keypad2,
+	"kp3":        keypad3,
+	"kp4":        keypad4,
+	"kp5":        keypad5,
+	"kp6":        keypad6,
+	"kp7":        keypad7,
+	"kp8":        keypad8,
+	"kp9":        keypad9,
+	"kpdecimal":  keypadDecimal,
+	"kpplus":     input.NumpadAdd,
+	"kpminus":    input.NumpadSubtract,
+	"kpmultiply": input.NumpadMultiply,
+	"kpdivide":   input.NumpadDivide,
+	"kpenter":    input.NumpadEnter,
+}
+
+// keyAliases maps alternative key names to their names in keyMap.
+var keyAliases = map[string]string{
+	"esc":    "escape",
+	"return": "enter",
+	"del":    "delete",
+	"ins":    "insert",
+	"pgup":   "pageup",
+	"pgdn":   "pagedown",
+}
+
+// shiftedFunctionKeys maps F13–F24 to the keys they are sent as. Few keyboards
+// have them, and xterm (like its terminfo entry) treats shift+F1–F12 as F13–F24.
+var shiftedFunctionKeys = map[string]input.Key{
+	"f13": input.F1, "f14": input.F2, "f15": input.F3, "f16": input.F4,
+	"f17": input.F5, "f18": input.F6, "f19": input.F7, "f20": input.F8,
+	"f21": input.F9, "f22": input.F10, "f23": input.F11, "f24": input.F12,
+}
+
+// modifierKeys maps modifier names to the keys held for them.
+var modifierKeys = map[string]input.Key{
+	"ctrl":  input.ControlLeft,
+	"alt":   input.AltLeft,
+	"shift": input.ShiftLeft,
+	"meta":  input.MetaLeft,
+}
+
+// modifierAliases maps alternative modifier names to their names in modifierKeys.
+var modifierAliases = map[string]string{
+	"control": "ctrl",
+	"option":  "alt",
+	"cmd":     "meta",
+	"super":   "meta",
+}
+
+// modifierOrder is the order modifiers are pressed in, whatever order a
+// combination names them in. They are released in reverse.
+var modifierOrder = []string{"ctrl", "alt", "shift", "meta"}
+
+// characterKeyMap maps characters to input.Key constants for modifier combinations.
+// Used by parseKeyCombo for keys that require physical keyboard simulation
+// via CDP key events. Single printable characters without modifiers bypass this
+// map entirely and are sent directly via xterm's term.input() API to support Unicode,
+// emoji, and grapheme clusters that can't be mapped to physical keys.
+var characterKeyMap = map[rune]input.Key{
+	// Letters
+	'a': input.KeyA, 'b': input.KeyB, 'c': input.KeyC, 'd': input.KeyD,
+	'e': input.KeyE, 'f': input.KeyF, 'g': input.KeyG, 'h': input.KeyH,
+	'i': input.KeyI, 'j': input.KeyJ, 'k': input.KeyK, 'l': input.KeyL,
+	'm': input.KeyM, 'n': input.KeyN, 'o': input.KeyO, 'p': input.KeyP,
+	'q': input.KeyQ, 'r': input.KeyR, 's': input.KeyS, 't': input.KeyT,
+	'u': input.KeyU, 'v': input.KeyV, 'w': input.KeyW, 'x': input.KeyX,
+	'y': input.KeyY, 'z': input.KeyZ,
+	// Digits
+	'0': input.Digit0, '1': input.Digit1, '2': input.Digit2, '3': input.Digit3,
+	'4': input.Digit4, '5': input.Digit5, '6': input.Digit6, '7': input.Digit7,
+	'8': input.Digit8, '9': input.Digit9,
+	// Punctuation
+	'/': input.Slash, '\\': input.Backslash, '.': input.Period, ',': input.Comma,
+	';': input.Semicolon, '\'': input.Quote, '[': input.BracketLeft, ']': input.BracketRight,
+	'-': input.Minus, '=': input.Equal, '`': input.Backquote,
+}
+
+// shiftedCharacterKeyMap maps uppercase letters and shifted punctuation, like
+// A or !, to the keys that type them with shift held.
+var shiftedCharacterKeyMap = func() map[rune]input.Key {
+	m := make(map[rune]input.Key)
+	for _, k := range characterKeyMap {
+		if shifted, ok := k.Shift(); ok {
+			m[rune(shifted)] = k
+		}
+	}
+	return m
+}()
+
+// controlCharacters maps the keys that make a C0 control character with ctrl,
+// as in xterm, to that character. xterm.js encodes only some of them from key
+// events, so SendKey sends these as input instead.
+var controlCharacters = map[string]byte{
+	"@": 0x00, "space": 0x00, "2": 0x00,
+	"[": 0x1b, "3": 0x1b,
+	"\\": 0x1c, "4": 0x1c,
+	"]": 0x1d, "5": 0x1d,
+	"^": 0x1e, "6": 0x1e, "~": 0x1e,
+	"_": 0x1f, "/": 0x1f, "7": 0x1f,
+	"?": 0x7f, "8": 0x7f,
+}
+
+// KeyGroup is a group of related key names, as listed by KeyNames.
+type KeyGroup struct {
+	Name string
+	Note string // How the keys are used or sent, if not obvious
+	Keys []KeyName
+}
+
+// KeyName is a key name SendKey accepts, with any other names for the same key.
+type KeyName struct {
+	Name    string
+	Aliases []string
+}
+
+// keyGroups lists every key name SendKey accepts, for KeyNames.
+var keyGroups = []KeyGroup{
+	{Name: "Editing", Keys: keyNames("enter", "tab", "backspace", "delete", "insert", "escape", "space")},
+	{Name: "Navigation", Keys: keyNames("up", "down", "left", "right", "home", "end", "pageup", "pagedown")},
+	{
+		Name: "Function",
+		Note: "f13–f24 are sent as shift+f1–f12, as xterm does",
+		Keys: keyNames("f1", "f2", "f3", "f4", "f5", "f6", "f7", "f8", "f9", "f10", "f11", "f12",
+			"f13", "f14", "f15", "f16", "f17", "f18", "f19", "f20", "f21", "f22", "f23", "f24"),
+	},
+	{
+		Name: "Keypad",
+		Note: "Keypad keys with Num Lock on",
+		Keys: keyNames("kp0", "kp1", "kp2", "kp3", "kp4", "kp5", "kp6", "kp7", "kp8", "kp9",
+			"kpdecimal", "kpplus", "kpminus", "kpmultiply", "kpdivide", "kpenter"),
+	},
+	{
+		Name: "Characters",
+		Note: "Sent alone, any character is typed; combined with modifiers, uppercase letters and shifted punctuation add shift (except with ctrl, where case does not matter)",
+		Keys: characterNames("abcdefghijklmnopqrstuvwxyz0123456789`-=[]\\;',./"),
+	},
+	{
+		Name: "Shifted characters",
+		Keys: characterNames("ABCDEFGHIJKLMNOPQRSTUVWXYZ~!@#$%^&*()_+{}|:\"<>?"),
+	},
+	{
+		Name: "Modifiers",
+		Note: "Combine with a key using +, e.g. ctrl+c or ctrl+shift+up",
+		Keys: []KeyName{
+			{Name: "ctrl", Aliases: []string{"control"}},
+			{Name: "alt", Aliases: []string{"option"}},
+			{Name: "shift"},
+			{Name: "meta", Aliases: []string{"cmd", "super"}},
+		},
+	},
+	{
+		Name: "Control characters",
+		Note: "Sent as the C0 control character xterm sends for them",
+		Keys: []KeyName{
+			{Name: "ctrl+@", Aliases: []string{"ctrl+space", "ctrl+2"}},
+			{Name: "ctrl+[", Aliases: []string{"ctrl+3"}},
+			{Name: "ctrl+\\", Aliases: []string{"ctrl+4"}},
+			{Name: "ctrl+]", Aliases: []string{"ctrl+5"}},
+			{Name: "ctrl+^", Aliases: []string{"ctrl+6", "ctrl+~"}},
+			{Name: "ctrl+_", Aliases: []string{"ctrl+/", "ctrl+7"}},
+			{Name: "ctrl+?", Aliases: []string{"ctrl+8"}},
+		},
+	},
+}
+
+// keyNames returns the given key names with their aliases from keyAliases.
+func keyNames(names ...string) []KeyName {
+	keys := make([]KeyName, len(names))
+	for i, name := range names {
+		keys[i].Name = name
+		for alias, target := range keyAliases {
+			if target == name {
+				keys[i].Aliases = append(keys[i].Aliases, alias)
+			}
+		}
+		slices.Sort(keys[i].Aliases)
+	}
+	return keys
+}
+
+// characterNames returns a key name for each character in chars.
+func characterNames(chars string) []KeyName {
+	var keys []KeyName
+	for _, r := range chars {
+		keys = append(keys, KeyName{Name: string(r)})
+	}
+	return keys
+}
+
+// KeyNames returns every key name SendKey accepts, in groups, with their aliases.
+func KeyNames() []KeyGroup {
+	groups := slices.Clone(keyGroups)
+	for i := range groups {
+		groups[i].Keys = slices.Clone(groups[i].Keys)
+		for j := range groups[i].Keys {
+			groups[i].Keys[j].Aliases = slices.Clone(groups[i].Keys[j].Aliases)
+		}
+	}
+	return groups
+}
+
+// splitKeyCombo splits a combination like "ctrl+shift+p" into its canonical
+// modifier names and its key. "+" alone or a trailing "++" names the + key.
+func splitKeyCombo(combo string) ([]string, string, error) {
+	var rest, key string
+	if combo == "+" {
+		return nil, combo, nil
+	}
+	if strings.HasSuffix(combo, "++") {
+		rest, key = combo[:len(combo)-2], "+"
+	} else {
+		i := strings.LastIndex(combo, "+")
+		if i < 0 {
+			return nil, combo, nil
+		}
+		rest, key = combo[:i], combo[i+1:]
+	}
+	if key == "" {
+		return nil, "", fmt.Errorf("incomplete modifier combination: %q (missing key after +)", combo)
+	}
+
+	var modifiers []string
+	for _, name := range strings.Split(rest, "+") {
+		modifier := strings.ToLower(name)
+		if canonical, ok := modifierAliases[modifier]; ok {
+			modifier = canonical
+		}
+		if _, ok := modifierKeys[modifier]; !ok {
+			return nil, "", fmt.Errorf("unknown modifier %q in: %s", name, combo)
+		}
+		if slices.Contains(modifiers, modifier) {
+			return nil, "", fmt.Errorf("duplicate modifier %q in: %s", name, combo)
+		}
+		modifiers = append(modifiers, modifier)
+	}
+	return modifiers, key, nil
+}
+
+// parseKeyCombo resolves a key name with optional modifiers, like "up",
+// "ctrl+c" or "ctrl+shift+p", to the key and the modifiers to hold for it, in
+// the order they are pressed. Keys that are typed with shift, like A, ! or
+// F13, add shift to the modifiers, except that ctrl with a letter ignores its
+// case. With shift held, keys that have a shifted form (like a → A or 1 → !)
+// are returned in that form, as a real keyboard sends them.
+func parseKeyCombo(combo string) (input.Key, []input.Key, error) {
+	names, key, err := splitKeyCombo(combo)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	target, shift, err := resolveComboKey(key)
+	if err != nil {
+		if len(names) == 0 {
+			return 0, nil, err
+		}
+		return 0, nil, fmt.Errorf("%s: %w", combo, err)
+	}
+
+	caseless := slices.Contains(names, "ctrl") && len(key) == 1 && unicode.IsLetter(rune(key[0]))
+	if shift && !caseless && !slices.Contains(names, "shift") {
+		names = append(names, "shift")
+	}
+
+	var modifiers []input.Key
+	for _, modifier := range modifierOrder {
+		if slices.Contains(names, modifier) {
+			modifiers = append(modifiers, modifierKeys[modifier])
+		}
+	}
+
+	if slices.Contains(names, "shift") {
+		if shifted, ok := target.Shift(); ok {
+			target = shifted
+		}
+	}
+	return target, modifiers, nil
+}
+
+// controlCharacter returns the C0 control character a combination like
+// "ctrl+[" or "ctrl+space" makes, if it is one of controlCharacters.
+func controlCharacter(combo string) (byte, bool) {
+	names, key, err := splitKeyCombo(combo)
+	if err != nil || len(names) != 1 || names[0] != "ctrl" {
+		return 0, false
+	}
+	c, ok := controlCharacters[strings.ToLower(key)]
+	return c, ok
+}
+
+// resolveComboKey resolves the key of a combination, reporting whether the
+// name implies shift: an uppercase letter, shifted punctuation like ! or one
+// of F13–F24.
+func resolveComboKey(name string) (input.Key, bool, error) {
+	if k, ok := shiftedFunctionKeys[strings.ToLower(name)]; ok {
+		return k, true, nil
+	}
+	if r, size := utf8.DecodeRuneInString(name); size == len(name) {
+		if k, ok := shiftedCharacterKeyMap[r]; ok {
+			return k, true, nil
+		}
+	}
+	k, err := resolveTargetKey(name)
+	return k, false, err
+}
+
+// resolveTargetKey converts a key name or alias to an input.Key constant.
+// Checks keyMap first for named keys, then characterKeyMap for single characters.
+func resolveTargetKey(key string) (input.Key, error) {
+	name := strings.ToLower(key)
+	if canonical, ok := keyAliases[name]; ok {
+		name = canonical
+	}
+	if k, ok := keyMap[name]; ok {
+		return k, nil
+	}
+	if r, size := utf8.DecodeRuneInString(name); size == len(name) {
+		if k, ok := characterKeyMap[r]; ok {
+			return k, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown key: %s", key)
+}
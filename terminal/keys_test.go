@@ -0,0 +1,18 @@
+package terminal
+
+import "testing"
+
+func TestKeyNames(t *testing.T) {
+	for _, group := range KeyNames() {
+		for _, key := range group.Keys {
+			for _, name := range append([]string{key.Name}, key.Aliases...) {
+				if _, ok := controlCharacter(name); ok || group.Name == "Modifiers" {
+					continue
+				}
+				if _, _, err := parseKeyCombo(name); err != nil {
+					t.Errorf("parseKeyCombo(%q) [%s] failed: %v", name, group.Name, err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package terminal
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-rod/rod/lib/input"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// DefaultKeyboardLayout is the layout key combinations are typed on unless
+// WithKeyboardLayout picks another.
+const DefaultKeyboardLayout = "us"
+
+// layoutOverrides lists, for each keyboard layout, the physical keys that type
+// something other than on a US keyboard: each key's unshifted, shifted and
+// AltGr characters, in that order. An empty string means the key types no
+// character. Dead keys (like ^ on de) are treated as plain keys.
+var layoutOverrides = map[string][]struct{ code, chars string }{
+	DefaultKeyboardLayout: nil,
+	"de": {
+		{"Backquote", "^°"},
+		{"Digit2", "2\"²"}, {"Digit3", "3§³"}, {"Digit6", "6&"}, {"Digit7", "7/{"},
+		{"Digit8", "8(["}, {"Digit9", "9)]"}, {"Digit0", "0=}"},
+		{"Minus", "ß?\\"}, {"Equal", "´`"},
+		{"KeyQ", "qQ@"}, {"KeyE", "eE€"}, {"KeyY", "zZ"}, {"KeyZ", "yY"}, {"KeyM", "mMµ"},
+		{"BracketLeft", "üÜ"}, {"BracketRight", "+*~"},
+		{"Semicolon", "öÖ"}, {"Quote", "äÄ"}, {"Backslash", "#'"},
+		{"IntlBackslash", "<>|"},
+		{"Comma", ",;"}, {"Period", ".:"}, {"Slash", "-_"},
+	},
+	"fr": {
+		{"Backquote", "²"},
+		{"Digit1", "&1"}, {"Digit2", "é2~"}, {"Digit3", "\"3#"}, {"Digit4", "'4{"},
+		{"Digit5", "(5["}, {"Digit6", "-6|"}, {"Digit7", "è7`"}, {"Digit8", "_8\\"},
+		{"Digit9", "ç9^"}, {"Digit0", "à0@"},
+		{"Minus", ")°]"}, {"Equal", "=+}"},
+		{"KeyQ", "aA"}, {"KeyW", "zZ"}, {"KeyE", "eE€"}, {"KeyA", "qQ"}, {"KeyZ", "wW"},
+		{"BracketLeft", "^¨"}, {"BracketRight", "$£¤"},
+		{"Semicolon", "mM"}, {"Quote", "ù%"}, {"Backslash", "*µ"},
+		{"IntlBackslash", "<>"},
+		{"KeyM", ",?"}, {"Comma", ";."}, {"Period", ":/"}, {"Slash", "!§"},
+	},
+	"jp": {
+		{"Backquote", ""},
+		{"Digit2", "2\""}, {"Digit6", "6&"}, {"Digit7", "7'"}, {"Digit8", "8("},
+		{"Digit9", "9)"}, {"Digit0", "0"},
+		{"Minus", "-="}, {"Equal", "^~"}, {"IntlYen", "\\|"},
+		{"BracketLeft", "@`"}, {"BracketRight", "[{"}, {"Backslash", "]}"},
+		{"Semicolon", ";+"}, {"Quote", ":*"},
+		{"IntlRo", "\\_"},
+	},
+}
+
+// intlKeyCodes are the key codes of the keys a US keyboard lacks.
+var intlKeyCodes = map[string]int{
+	"IntlBackslash": 226,
+	"IntlRo":        226,
+	"IntlYen":       220,
+}
+
+// layoutKey is a physical key on a keyboard layout.
+type layoutKey struct {
+	code    string  // KeyboardEvent.code, which names the key's position
+	keyCode int     // KeyboardEvent.keyCode
+	chars   [3]rune // Unshifted, shifted and AltGr characters; 0 if none
+}
+
+// layoutChar is a character and how a keyboard layout types it.
+type layoutChar struct {
+	key   *layoutKey
+	level int // 0 unshifted, 1 with shift, 2 with AltGr
+}
+
+// keyboardLayout maps characters to the keys that type them on one layout.
+type keyboardLayout struct {
+	chars map[rune]layoutChar
+}
+
+// keyboardLayouts holds every layout in layoutOverrides, built from the US keys.
+var keyboardLayouts = func() map[string]*keyboardLayout {
+	// The US keys, by position, from characterKeyMap
+	us := make(map[string]*layoutKey)
+	for r, k := range characterKeyMap {
+		info := k.Info()
+		key := &layoutKey{code: info.Code, keyCode: info.KeyCode}
+		key.chars[0] = r
+		if shifted, ok := k.Shift(); ok {
+			key.chars[1] = rune(shifted)
+		}
+		us[info.Code] = key
+	}
+
+	layouts := make(map[string]*keyboardLayout)
+	for name, overrides := range layoutOverrides {
+		keys := make(map[string]*layoutKey, len(us))
+		for code, key := range us {
+			copied := *key
+			keys[code] = &copied
+		}
+		for _, o := range overrides {
+			key := &layoutKey{code: o.code}
+			copy(key.chars[:], []rune(o.chars))
+			key.keyCode = layoutKeyCode(key, us)
+			keys[o.code] = key
+		}
+
+		// Where several keys type a character, prefer the lowest level, then
+		// the first key by position name, so the choice is stable
+		layout := &keyboardLayout{chars: make(map[rune]layoutChar)}
+		for _, code := range slices.Sorted(maps.Keys(keys)) {
+			key := keys[code]
+			for level, r := range key.chars {
+				if r == 0 {
+					continue
+				}
+				if existing, ok := layout.chars[r]; !ok || level < existing.level {
+					layout.chars[r] = layoutChar{key: key, level: level}
+				}
+			}
+		}
+		layouts[name] = layout
+	}
+	return layouts
+}()
+
+// layoutKeyCode returns the keyCode Chrome reports for a key on a non-US
+// layout: the letter's code if it types an ASCII letter, the digit's if it
+// types an ASCII digit, and otherwise the code of the US key in its position.
+func layoutKeyCode(key *layoutKey, us map[string]*layoutKey) int {
+	if r := key.chars[0]; r < utf8.RuneSelf && unicode.IsLetter(r) {
+		return int(unicode.ToUpper(r))
+	}
+	for _, r := range key.chars[:2] {
+		if r >= '0' && r <= '9' {
+			return int(r)
+		}
+	}
+	if usKey, ok := us[key.code]; ok {
+		return usKey.keyCode
+	}
+	return intlKeyCodes[key.code]
+}
+
+// KeyboardLayouts returns the names of the keyboard layouts WithKeyboardLayout accepts.
+func KeyboardLayouts() []string {
+	return slices.Sorted(maps.Keys(layoutOverrides))
+}
+
+// KeyboardLayout returns the name of the layout key combinations are typed on.
+func (t *Terminal) KeyboardLayout() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.layoutName()
+}
+
+// layoutName returns the configured layout's name.
+func (c *config) layoutName() string {
+	if c.keyboardLayout == "" {
+		return DefaultKeyboardLayout
+	}
+	return c.keyboardLayout
+}
+
+// layoutCombo resolves a combination of modifiers with a single character,
+// like "ctrl+ö" or "alt+@", on the configured layout: the character that the
+// key event carries, the key that types it and the modifiers to hold. It
+// returns ok false on the US layout and for named keys like "enter", which
+// every layout types alike.
+func (c *config) layoutCombo(combo string) (key rune, lk *layoutKey, modifiers []input.Key, ok bool, err error) {
+	if c.layoutName() == DefaultKeyboardLayout {
+		return 0, nil, nil, false, nil
+	}
+	names, name, err := splitKeyCombo(combo)
+	if err != nil {
+		return 0, nil, nil, false, nil
+	}
+	r, size := utf8.DecodeRuneInString(name)
+	if size != len(name) || r == ' ' {
+		return 0, nil, nil, false, nil
+	}
+
+	// As in parseKeyCombo, case does not matter with ctrl
+	if slices.Contains(names, "ctrl") && unicode.IsLetter(r) {
+		r = unicode.ToLower(r)
+	}
+	char, found := keyboardLayouts[c.layoutName()].chars[r]
+	if !found {
+		return 0, nil, nil, true, fmt.Errorf("%s: no key types %q on the %s keyboard layout", combo, r, c.layoutName())
+	}
+
+	level := char.level
+	if level == 1 && !slices.Contains(names, "shift") {
+		names = append(names, "shift")
+	}
+	if level == 0 && slices.Contains(names, "shift") && char.key.chars[1] != 0 {
+		level = 1
+	}
+	for _, modifier := range modifierOrder {
+		if slices.Contains(names, modifier) {
+			modifiers = append(modifiers, modifierKeys[modifier])
+		}
+	}
+	if level == 2 {
+		// AltGr is its own key on Linux rather than ctrl+alt, as on Windows
+		modifiers = append(modifiers, input.AltGraph)
+	}
+	return char.key.chars[level], char.key, modifiers, true, nil
+}
+
+// layoutKeyEncoder returns an encoder for the key event of a layout key typing key.
+func layoutKeyEncoder(key rune, lk *layoutKey) func(proto.InputDispatchKeyEventType, int) *proto.InputDispatchKeyEvent {
+	return func(eventType proto.InputDispatchKeyEventType, modifiers int) *proto.InputDispatchKeyEvent {
+		e := &proto.InputDispatchKeyEvent{
+			Type:                  eventType,
+			WindowsVirtualKeyCode: lk.keyCode,
+			Code:                  lk.code,
+			Key:                   string(key),
+			Modifiers:             modifiers,
+		}
+		if eventType == proto.InputDispatchKeyEventTypeKeyDown {
+			e.Text = string(key)
+			e.UnmodifiedText = string(key)
+		}
+		return e
+	}
+}
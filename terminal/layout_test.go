@@ -0,0 +1,53 @@
+package terminal
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestLayoutCombo(t *testing.T) {
+	tests := []struct {
+		layout, combo string
+		key           rune
+		code          string
+		keyCode       int
+		modifiers     []string
+	}{
+		{"de", "ctrl+z", 'z', "KeyY", 90, []string{"Control"}},
+		{"de", "ctrl+ö", 'ö', "Semicolon", 186, []string{"Control"}},
+		{"de", "alt+@", '@', "KeyQ", 81, []string{"Alt", "AltGraph"}},
+		{"de", "alt+?", '?', "Minus", 189, []string{"Alt", "Shift"}},
+		{"fr", "ctrl+a", 'a', "KeyQ", 65, []string{"Control"}},
+		{"fr", "alt+1", '1', "Digit1", 49, []string{"Alt", "Shift"}},
+		{"jp", "alt+@", '@', "BracketLeft", 219, []string{"Alt"}},
+	}
+	for _, tc := range tests {
+		cfg := defaultConfig()
+		WithKeyboardLayout(tc.layout)(&cfg)
+		key, lk, modifiers, ok, err := cfg.layoutCombo(tc.combo)
+		if err != nil || !ok {
+			t.Errorf("layoutCombo(%q) on %s = %t, %v", tc.combo, tc.layout, ok, err)
+			continue
+		}
+		var names []string
+		for _, m := range modifiers {
+			names = append(names, m.Info().Key)
+		}
+		if key != tc.key || lk.code != tc.code || lk.keyCode != tc.keyCode || !slices.Equal(names, tc.modifiers) {
+			t.Errorf("layoutCombo(%q) on %s = %q %s %d %v; want %q %s %d %v", tc.combo, tc.layout,
+				key, lk.code, lk.keyCode, names, tc.key, tc.code, tc.keyCode, tc.modifiers)
+		}
+	}
+
+	cfg := defaultConfig()
+	WithKeyboardLayout("de")(&cfg)
+	if _, _, _, _, err := cfg.layoutCombo("alt+ç"); err == nil {
+		t.Error("layoutCombo(alt+ç) on de: expected error, got nil")
+	}
+	if _, _, _, ok, _ := cfg.layoutCombo("ctrl+enter"); ok {
+		t.Error("layoutCombo(ctrl+enter) on de: named keys should not depend on the layout")
+	}
+	if _, err := New(WithKeyboardLayout("xx")); err == nil {
+		t.Error("New(WithKeyboardLayout(xx)): expected error, got nil")
+	}
+}
@@ -0,0 +1,234 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// MouseButton is a mouse button for MouseClick and MouseDrag.
+type MouseButton string
+
+// Mouse buttons.
+const (
+	MouseLeft   MouseButton = "left"
+	MouseMiddle MouseButton = "middle"
+	MouseRight  MouseButton = "right"
+)
+
+// mouseButtons maps each button to its CDP button and its bit in the
+// CDP buttons mask.
+var mouseButtons = map[MouseButton]struct {
+	button proto.InputMouseButton
+	mask   int
+}{
+	MouseLeft:   {proto.InputMouseButtonLeft, 1},
+	MouseRight:  {proto.InputMouseButtonRight, 2},
+	MouseMiddle: {proto.InputMouseButtonMiddle, 4},
+}
+
+// MouseClick clicks button on the cell at row, col (zero-based, from the top
+// left of the screen) clicks times, e.g. 2 for a double click. The events go
+// through xterm.js like real mouse input, so an app that enabled mouse
+// reporting receives the matching X10/SGR mouse reports.
+func (t *Terminal) MouseClick(ctx context.Context, row, col int, button MouseButton, clicks int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return err
+	}
+	if clicks < 1 {
+		return fmt.Errorf("click count must be at least 1, got %d", clicks)
+	}
+	b, ok := mouseButtons[button]
+	if !ok {
+		return fmt.Errorf("unknown mouse button %q", button)
+	}
+	x, y, err := t.cellCenterUnlocked(ctx, row, col)
+	if err != nil {
+		return err
+	}
+
+	if err := t.moveMouse(ctx, x, y, 0); err != nil {
+		return fmt.Errorf("failed to move mouse: %w", err)
+	}
+	for i := 1; i <= clicks; i++ {
+		if err := t.pressMouse(ctx, x, y, b.button, b.mask, i); err != nil {
+			return fmt.Errorf("failed to click: %w", err)
+		}
+		if err := t.releaseMouse(x, y, b.button, i); err != nil {
+			return fmt.Errorf("failed to click: %w", err)
+		}
+	}
+	return nil
+}
+
+// MouseScroll turns the mouse wheel over the cell at row, col (zero-based) by
+// lines: negative scrolls up, positive scrolls down. Each line is a separate
+// wheel event, so an app with mouse reporting receives one report per line.
+func (t *Terminal) MouseScroll(ctx context.Context, row, col, lines int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return err
+	}
+	if lines == 0 {
+		return fmt.Errorf("lines cannot be zero")
+	}
+	m, err := t.measureCellsUnlocked(ctx)
+	if err != nil {
+		return err
+	}
+	x, y, err := t.cellCenter(m, row, col)
+	if err != nil {
+		return err
+	}
+
+	if err := t.moveMouse(ctx, x, y, 0); err != nil {
+		return fmt.Errorf("failed to move mouse: %w", err)
+	}
+
+	// One cell height of pixel delta is exactly one line to xterm.js
+	delta := m.CellHeight
+	if lines < 0 {
+		delta, lines = -delta, -lines
+	}
+	page := t.page.Context(ctx)
+	for range lines {
+		err := proto.InputDispatchMouseEvent{
+			Type:   proto.InputDispatchMouseEventTypeMouseWheel,
+			X:      x,
+			Y:      y,
+			DeltaY: delta,
+		}.Call(page)
+		if err != nil {
+			return fmt.Errorf("failed to scroll: %w", err)
+		}
+	}
+	return nil
+}
+
+// MouseDrag presses button on the cell at fromRow, fromCol, moves through
+// every cell on the straight line to toRow, toCol and releases it there, so
+// the app sees a motion report for each cell crossed. Coordinates are zero-based.
+func (t *Terminal) MouseDrag(ctx context.Context, fromRow, fromCol, toRow, toCol int, button MouseButton) (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return err
+	}
+	b, ok := mouseButtons[button]
+	if !ok {
+		return fmt.Errorf("unknown mouse button %q", button)
+	}
+	m, err := t.measureCellsUnlocked(ctx)
+	if err != nil {
+		return err
+	}
+	fromX, fromY, err := t.cellCenter(m, fromRow, fromCol)
+	if err != nil {
+		return err
+	}
+	toX, toY, err := t.cellCenter(m, toRow, toCol)
+	if err != nil {
+		return err
+	}
+
+	if err := t.moveMouse(ctx, fromX, fromY, 0); err != nil {
+		return fmt.Errorf("failed to move mouse: %w", err)
+	}
+
+	if err := t.pressMouse(ctx, fromX, fromY, b.button, b.mask, 1); err != nil {
+		return fmt.Errorf("failed to drag: %w", err)
+	}
+
+	// Release wherever the pointer got to, even if ctx is cancelled midway
+	x, y := fromX, fromY
+	defer func() {
+		releaseErr := t.releaseMouse(x, y, b.button, 1)
+		if err == nil && releaseErr != nil {
+			err = fmt.Errorf("failed to drag: %w", releaseErr)
+		}
+	}()
+
+	steps := max(abs(toRow-fromRow), abs(toCol-fromCol))
+	for i := 1; i <= steps; i++ {
+		nextX := fromX + (toX-fromX)*float64(i)/float64(steps)
+		nextY := fromY + (toY-fromY)*float64(i)/float64(steps)
+		if err := t.moveMouse(ctx, nextX, nextY, b.mask); err != nil {
+			return fmt.Errorf("failed to drag: %w", err)
+		}
+		x, y = nextX, nextY
+	}
+	return nil
+}
+
+// cellCenterUnlocked returns the page coordinates of the center of the cell
+// at row, col. Caller must hold at least the read lock.
+func (t *Terminal) cellCenterUnlocked(ctx context.Context, row, col int) (x, y float64, err error) {
+	m, err := t.measureCellsUnlocked(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	return t.cellCenter(m, row, col)
+}
+
+// cellCenter returns the page coordinates of the center of the cell at
+// row, col, given the grid's metrics.
+func (t *Terminal) cellCenter(m cellMetrics, row, col int) (x, y float64, err error) {
+	if row < 0 || row >= t.rows || col < 0 || col >= t.cols {
+		return 0, 0, fmt.Errorf("cell %d,%d is outside the %dx%d screen", row, col, t.rows, t.cols)
+	}
+	x = m.Left + (float64(col)+0.5)*m.CellWidth
+	y = m.Top + (float64(row)+0.5)*m.CellHeight
+	return x, y, nil
+}
+
+// moveMouse moves the pointer to x, y with the buttons in mask held.
+func (t *Terminal) moveMouse(ctx context.Context, x, y float64, mask int) error {
+	return proto.InputDispatchMouseEvent{
+		Type:    proto.InputDispatchMouseEventTypeMouseMoved,
+		X:       x,
+		Y:       y,
+		Buttons: &mask,
+	}.Call(t.page.Context(ctx))
+}
+
+// pressMouse presses button at x, y.
+func (t *Terminal) pressMouse(ctx context.Context, x, y float64, button proto.InputMouseButton, mask, clickCount int) error {
+	return proto.InputDispatchMouseEvent{
+		Type:       proto.InputDispatchMouseEventTypeMousePressed,
+		X:          x,
+		Y:          y,
+		Button:     button,
+		Buttons:    &mask,
+		ClickCount: clickCount,
+	}.Call(t.page.Context(ctx))
+}
+
+// releaseMouse releases button at x, y. Like key releases in pressKey, it is
+// not bound to a request context, so no button is left held down in the
+// browser when a request is cancelled.
+func (t *Terminal) releaseMouse(x, y float64, button proto.InputMouseButton, clickCount int) error {
+	none := 0
+	return proto.InputDispatchMouseEvent{
+		Type:       proto.InputDispatchMouseEventTypeMouseReleased,
+		X:          x,
+		Y:          y,
+		Button:     button,
+		Buttons:    &none,
+		ClickCount: clickCount,
+	}.Call(t.page)
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
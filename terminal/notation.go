@@ -0,0 +1,307 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+)
+
+// NotationStyle is a key notation understood by ParseKeyNotation.
+type NotationStyle string
+
+// Key notation styles.
+const (
+	// NotationVim is Vim's key notation: literal text mixed with bracketed
+	// keys, like "<Esc>:wq<CR>" or "<C-x><C-s>". Write <lt> for a literal <.
+	NotationVim NotationStyle = "vim"
+
+	// NotationEmacs is the notation of Emacs's kbd: keys separated by spaces,
+	// like "C-x C-s", "C-M-f" or "M-x find-file RET". A word that is not a key
+	// is typed as literal text; write SPC for a space.
+	NotationEmacs NotationStyle = "emacs"
+)
+
+// NotationError reports the token of a key notation that failed to parse.
+type NotationError struct {
+	Offset int    // Byte offset of the token in the notation
+	Token  string // The token as written
+	Err    error
+}
+
+func (e *NotationError) Error() string {
+	return fmt.Sprintf("%q at offset %d: %v", e.Token, e.Offset, e.Err)
+}
+
+func (e *NotationError) Unwrap() error {
+	return e.Err
+}
+
+// vimModifiers maps Vim's modifier prefixes to modifier names.
+var vimModifiers = map[byte]string{
+	'C': "ctrl", 'c': "ctrl",
+	'S': "shift", 's': "shift",
+	'M': "alt", 'm': "alt",
+	'A': "alt", 'a': "alt",
+	'D': "meta", 'd': "meta",
+}
+
+// vimKeyNames maps Vim key names that SendKey does not know, lowercased, to
+// their SendKey names.
+var vimKeyNames = map[string]string{
+	"cr":        "enter",
+	"nl":        "ctrl+j",
+	"bs":        "backspace",
+	"nul":       "ctrl+@",
+	"lt":        "<",
+	"bslash":    "\\",
+	"bar":       "|",
+	"kplus":     "kpplus",
+	"kminus":    "kpminus",
+	"kmultiply": "kpmultiply",
+	"kdivide":   "kpdivide",
+	"kenter":    "kpenter",
+	"kpoint":    "kpdecimal",
+	"k0":        "kp0", "k1": "kp1", "k2": "kp2", "k3": "kp3", "k4": "kp4",
+	"k5": "kp5", "k6": "kp6", "k7": "kp7", "k8": "kp8", "k9": "kp9",
+}
+
+// emacsModifiers maps Emacs's modifier prefixes to modifier names. Meta is the
+// Alt key in a terminal, and super is the key SendKey calls meta.
+var emacsModifiers = map[byte]string{
+	'C': "ctrl",
+	'M': "alt",
+	'A': "alt",
+	'S': "shift",
+	's': "meta",
+}
+
+// emacsKeyNames maps the names Emacs writes without brackets to their SendKey names.
+var emacsKeyNames = map[string]string{
+	"RET": "enter",
+	"SPC": "space",
+	"TAB": "tab",
+	"ESC": "escape",
+	"DEL": "backspace",
+	"LFD": "ctrl+j",
+	"NUL": "ctrl+@",
+}
+
+// emacsBracketedKeyNames maps Emacs's bracketed key names that SendKey does
+// not know to their SendKey names.
+var emacsBracketedKeyNames = map[string]string{
+	"prior":       "pageup",
+	"next":        "pagedown",
+	"kp-add":      "kpplus",
+	"kp-subtract": "kpminus",
+	"kp-multiply": "kpmultiply",
+	"kp-divide":   "kpdivide",
+	"kp-decimal":  "kpdecimal",
+	"kp-enter":    "kpenter",
+	"kp-0":        "kp0", "kp-1": "kp1", "kp-2": "kp2", "kp-3": "kp3", "kp-4": "kp4",
+	"kp-5": "kp5", "kp-6": "kp6", "kp-7": "kp7", "kp-8": "kp8", "kp-9": "kp9",
+}
+
+// ParseKeyNotation converts keys written in Vim or Emacs notation into the key
+// names SendKeys accepts, one per key. A token that does not parse is
+// reported as a *NotationError with its offset.
+func ParseKeyNotation(notation string, style NotationStyle) ([]string, error) {
+	switch style {
+	case NotationVim:
+		return parseVimNotation(notation)
+	case NotationEmacs:
+		return parseEmacsNotation(notation)
+	default:
+		return nil, fmt.Errorf("unknown key notation %q", style)
+	}
+}
+
+// parseVimNotation parses Vim key notation.
+func parseVimNotation(notation string) ([]string, error) {
+	var keys []string
+	for i := 0; i < len(notation); {
+		if notation[i] != '<' {
+			key, size, err := literalKey(notation[i:])
+			if err != nil {
+				return nil, &NotationError{Offset: i, Token: notation[i : i+size], Err: err}
+			}
+			keys = append(keys, key)
+			i += size
+			continue
+		}
+
+		// The key itself may be >, as in <C->>
+		end := strings.IndexByte(notation[i+1:], '>')
+		if end < 0 {
+			return nil, &NotationError{Offset: i, Token: notation[i:], Err: errors.New("missing closing > (write <lt> for a literal <)")}
+		}
+		end += i + 1
+		if strings.HasSuffix(notation[i+1:end], "-") && strings.HasPrefix(notation[end+1:], ">") {
+			end++
+		}
+		token := notation[i : end+1]
+
+		key, err := vimKey(notation[i+1 : end])
+		if err != nil {
+			return nil, &NotationError{Offset: i, Token: token, Err: err}
+		}
+		keys = append(keys, key)
+		i = end + 1
+	}
+	return keys, nil
+}
+
+// vimKey converts the inside of a bracketed Vim key, like "C-x" or "Esc".
+func vimKey(name string) (string, error) {
+	modifiers, name := splitModifierPrefixes(name, vimModifiers)
+	if name == "" {
+		return "", errors.New("empty key")
+	}
+	if mapped, ok := vimKeyNames[strings.ToLower(name)]; ok {
+		name = mapped
+	}
+	return joinKeyCombo(modifiers, name)
+}
+
+// parseEmacsNotation parses Emacs kbd notation.
+func parseEmacsNotation(notation string) ([]string, error) {
+	var keys []string
+	for i := 0; i < len(notation); {
+		if isNotationSpace(notation[i]) {
+			i++
+			continue
+		}
+		end := i
+		for end < len(notation) && !isNotationSpace(notation[end]) {
+			end++
+		}
+		word := notation[i:end]
+
+		wordKeys, err := emacsWord(word)
+		if err != nil {
+			return nil, &NotationError{Offset: i, Token: word, Err: err}
+		}
+		keys = append(keys, wordKeys...)
+		i = end
+	}
+	return keys, nil
+}
+
+// isNotationSpace reports whether b separates the words of Emacs notation.
+func isNotationSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+// emacsWord converts one space-separated word of Emacs notation: a key with
+// optional modifiers, like "C-x", "RET" or "<f1>", or literal text.
+func emacsWord(word string) ([]string, error) {
+	modifiers, name := splitModifierPrefixes(word, emacsModifiers)
+	if len(name) > 2 && name[0] == '<' && name[len(name)-1] == '>' {
+		inner, bracketed := splitModifierPrefixes(name[1:len(name)-1], emacsModifiers)
+		modifiers = append(modifiers, inner...)
+		if mapped, ok := emacsBracketedKeyNames[strings.ToLower(bracketed)]; ok {
+			bracketed = mapped
+		}
+		key, err := joinKeyCombo(modifiers, bracketed)
+		return []string{key}, err
+	}
+	if mapped, ok := emacsKeyNames[name]; ok {
+		key, err := joinKeyCombo(modifiers, mapped)
+		return []string{key}, err
+	}
+	if len(modifiers) > 0 {
+		if !isSingleGrapheme(name) {
+			return nil, fmt.Errorf("modifiers need a single key, got %q", name)
+		}
+		key, err := joinKeyCombo(modifiers, name)
+		return []string{key}, err
+	}
+
+	// Anything else is literal text, typed a character at a time
+	var keys []string
+	for rest := word; rest != ""; {
+		key, size, err := literalKey(rest)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		rest = rest[size:]
+	}
+	return keys, nil
+}
+
+// splitModifierPrefixes strips modifier prefixes like "C-" and "M-" from the
+// front of name, returning the modifier names and the rest. A final "-" is
+// the key itself, as in "C--".
+func splitModifierPrefixes(name string, prefixes map[byte]string) ([]string, string) {
+	var modifiers []string
+	for len(name) > 2 && name[1] == '-' {
+		modifier, ok := prefixes[name[0]]
+		if !ok {
+			break
+		}
+		modifiers = append(modifiers, modifier)
+		name = name[2:]
+	}
+	return modifiers, name
+}
+
+// joinKeyCombo combines modifier names with a key name, which may carry
+// modifiers of its own, into a key name SendKey accepts.
+func joinKeyCombo(modifiers []string, key string) (string, error) {
+	held, key, err := splitKeyCombo(key)
+	if err != nil {
+		return "", err
+	}
+	if utf8.RuneCountInString(key) > 1 {
+		key = strings.ToLower(key)
+	}
+	for _, modifier := range modifiers {
+		if slices.Contains(held, modifier) {
+			return "", fmt.Errorf("duplicate modifier %q", modifier)
+		}
+		held = append(held, modifier)
+	}
+
+	var parts []string
+	for _, modifier := range modifierOrder {
+		if slices.Contains(held, modifier) {
+			parts = append(parts, modifier)
+		}
+	}
+	combo := strings.Join(append(parts, key), "+")
+
+	if len(parts) == 0 && isSingleGrapheme(key) {
+		return combo, nil
+	}
+	if _, ok := controlCharacter(combo); ok {
+		return combo, nil
+	}
+	if _, _, err := parseKeyCombo(combo); err != nil {
+		return "", err
+	}
+	return combo, nil
+}
+
+// literalKey returns the key that types the first grapheme of text, and the
+// grapheme's length in bytes.
+func literalKey(text string) (string, int, error) {
+	graphemes := uniseg.NewGraphemes(text)
+	graphemes.Next()
+	grapheme := graphemes.Str()
+
+	switch grapheme {
+	case "\n", "\r", "\t", " ":
+		return grapheme, len(grapheme), nil
+	}
+	if !utf8.ValidString(grapheme) {
+		return "", len(grapheme), fmt.Errorf("invalid utf-8 %q", grapheme)
+	}
+	if !isPrintableString(grapheme) {
+		return "", len(grapheme), fmt.Errorf("non-printable character %q (write it as a key, like <C-x>)", grapheme)
+	}
+	return grapheme, len(grapheme), nil
+}
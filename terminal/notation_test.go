@@ -0,0 +1,58 @@
+package terminal
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseKeyNotation(t *testing.T) {
+	tests := []struct {
+		notation string
+		style    NotationStyle
+		want     []string
+	}{
+		{"<Esc>:wq<CR>", NotationVim, []string{"esc", ":", "w", "q", "enter"}},
+		{"<C-x><C-s>", NotationVim, []string{"ctrl+x", "ctrl+s"}},
+		{"a<lt>b <C-->", NotationVim, []string{"a", "<", "b", " ", "ctrl+-"}},
+		{"<C-Space><S-Tab><M-A><k5><C-Bslash>", NotationVim, []string{"ctrl+space", "shift+tab", "alt+A", "kp5", "ctrl+\\"}},
+		{"C-x C-s", NotationEmacs, []string{"ctrl+x", "ctrl+s"}},
+		{"C-M-f", NotationEmacs, []string{"ctrl+alt+f"}},
+		{"M-x ls RET", NotationEmacs, []string{"alt+x", "l", "s", "enter"}},
+		{"C-<f1> <prior> SPC DEL", NotationEmacs, []string{"ctrl+f1", "pageup", "space", "backspace"}},
+	}
+	for _, tc := range tests {
+		got, err := ParseKeyNotation(tc.notation, tc.style)
+		if err != nil {
+			t.Errorf("ParseKeyNotation(%q, %s) failed: %v", tc.notation, tc.style, err)
+			continue
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("ParseKeyNotation(%q, %s) = %q, want %q", tc.notation, tc.style, got, tc.want)
+		}
+	}
+
+	errorTests := []struct {
+		notation string
+		style    NotationStyle
+		offset   int
+	}{
+		{"ab<Foo>", NotationVim, 2},
+		{"ab<C-x", NotationVim, 2},
+		{"<>", NotationVim, 0},
+		{"<C-C-x>", NotationVim, 0},
+		{"x\x01", NotationVim, 1},
+		{"C-x  C-foo", NotationEmacs, 5},
+	}
+	for _, tc := range errorTests {
+		_, err := ParseKeyNotation(tc.notation, tc.style)
+		var notationErr *NotationError
+		if !errors.As(err, &notationErr) {
+			t.Errorf("ParseKeyNotation(%q, %s): expected NotationError, got %v", tc.notation, tc.style, err)
+			continue
+		}
+		if notationErr.Offset != tc.offset {
+			t.Errorf("ParseKeyNotation(%q, %s): offset %d, want %d", tc.notation, tc.style, notationErr.Offset, tc.offset)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package terminal
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// config holds the settings controlled by Options.
+type config struct {
+	shell          string
+	args           []string // Exact argv to run; takes precedence over shell when set
+	rows           int
+	cols           int
+	dir            string
+	env            map[string]string
+	startupTimeout time.Duration
+	healthInterval time.Duration
+	autoReconnect  bool
+	tmuxSocket     string
+	direct         bool   // Run the command directly under ttyd, without tmux
+	pace           Pace   // Default pace of Type and SendKeys
+	keyboardLayout string // Layout key combinations are typed on; "" is DefaultKeyboardLayout
+}
+
+// defaultConfig returns the settings used when no Option overrides them.
+func defaultConfig() config {
+	return config{
+		shell:          defaultShell(),
+		rows:           24,
+		cols:           80,
+		startupTimeout: DefaultStartupTimeout,
+		healthInterval: DefaultHealthCheckInterval,
+		tmuxSocket:     DefaultTmuxSocket,
+	}
+}
+
+// validate reports settings that cannot start a terminal.
+func (c *config) validate() error {
+	if len(c.args) > 0 {
+		if c.args[0] == "" {
+			return fmt.Errorf("command cannot be empty")
+		}
+	} else if c.shell == "" {
+		return fmt.Errorf("shell cannot be empty")
+	}
+	if c.rows < 1 || c.cols < 1 {
+		return fmt.Errorf("invalid terminal size %dx%d", c.rows, c.cols)
+	}
+	if c.dir != "" {
+		info, err := os.Stat(c.dir)
+		if err != nil {
+			return fmt.Errorf("invalid working directory: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("invalid working directory: %s is not a directory", c.dir)
+		}
+	}
+	for key := range c.env {
+		if key == "" || strings.Contains(key, "=") {
+			return fmt.Errorf("invalid environment variable name %q", key)
+		}
+	}
+	if c.tmuxSocket == "" {
+		return fmt.Errorf("tmux socket cannot be empty")
+	}
+	if c.pace.Delay < 0 || c.pace.Jitter < 0 {
+		return fmt.Errorf("typing delay and jitter cannot be negative, got %v and %v", c.pace.Delay, c.pace.Jitter)
+	}
+	if _, ok := keyboardLayouts[c.layoutName()]; !ok {
+		return fmt.Errorf("unknown keyboard layout %q (known: %s)", c.keyboardLayout, strings.Join(KeyboardLayouts(), ", "))
+	}
+	if c.startupTimeout <= 0 {
+		return fmt.Errorf("startup timeout must be positive, got %v", c.startupTimeout)
+	}
+	if c.healthInterval < 0 {
+		return fmt.Errorf("health check interval cannot be negative, got %v", c.healthInterval)
+	}
+	return nil
+}
+
+// defaultShell returns the user's login shell, falling back to /bin/bash.
+func defaultShell() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	return "/bin/bash"
+}
+
+// Option configures a Terminal. Options are passed to New and Restart.
+type Option func(*config)
+
+// WithShell sets the shell or shell command to run. Absolute paths without
+// arguments (like /bin/zsh) run as an interactive login shell; anything else
+// is run via sh -c. Defaults to $SHELL, or /bin/bash if unset. It replaces any
+// command set by WithArgs.
+func WithShell(shell string) Option {
+	return func(c *config) {
+		c.shell = shell
+		c.args = nil
+	}
+}
+
+// WithArgs sets the exact argv to run, with args[0] looked up in PATH. The
+// arguments reach the command verbatim, with no shell interpretation, so
+// WithArgs("/usr/bin/app", "--name", "two words") behaves like exec. It
+// replaces any command set by WithShell.
+func WithArgs(args ...string) Option {
+	return func(c *config) {
+		c.args = slices.Clone(args)
+	}
+}
+
+// WithSize sets the terminal dimensions. Defaults to 24 rows by 80 columns.
+func WithSize(rows, cols int) Option {
+	return func(c *config) {
+		c.rows = rows
+		c.cols = cols
+	}
+}
+
+// WithDir sets the working directory of the command. Relative paths are
+// resolved against the current directory. Defaults to the tmux server's
+// default, which is usually the directory imprint was started in.
+func WithDir(dir string) Option {
+	return func(c *config) {
+		if abs, err := filepath.Abs(dir); err == nil && dir != "" {
+			dir = abs
+		}
+		c.dir = dir
+	}
+}
+
+// WithEnv sets extra environment variables for the command, on top of the
+// environment it inherits. It replaces any variables set by an earlier WithEnv,
+// so Restart(ctx, WithEnv(nil)) clears them.
+func WithEnv(env map[string]string) Option {
+	return func(c *config) {
+		c.env = maps.Clone(env)
+	}
+}
+
+// WithStartupTimeout bounds how long Start and Restart wait for ttyd, the
+// browser page and the tmux pane to become ready. Defaults to DefaultStartupTimeout.
+func WithStartupTimeout(d time.Duration) Option {
+	return func(c *config) {
+		c.startupTimeout = d
+	}
+}
+
+// WithHealthCheckInterval sets how often the background supervisor checks
+// that Chrome, the page, ttyd and tmux are alive. Zero disables the supervisor.
+// Defaults to DefaultHealthCheckInterval.
+func WithHealthCheckInterval(d time.Duration) Option {
+	return func(c *config) {
+		c.healthInterval = d
+	}
+}
+
+// WithAutoReconnect makes the supervisor reconnect a fresh page (restarting
+// ttyd and Chrome as needed) to the surviving tmux session when a component
+// dies, so the app under test keeps its state.
+func WithAutoReconnect(enabled bool) Option {
+	return func(c *config) {
+		c.autoReconnect = enabled
+	}
+}
+
+// WithTmuxSocket sets the tmux server imprint runs its sessions on: a socket
+// name (tmux -L) or, if it contains a slash, a socket path (tmux -S). The
+// server is started with a built-in minimal config instead of ~/.tmux.conf.
+// Defaults to DefaultTmuxSocket.
+func WithTmuxSocket(socket string) Option {
+	return func(c *config) {
+		c.tmuxSocket = socket
+	}
+}
+
+// WithDirect runs the command directly under ttyd instead of inside a tmux
+// session, for apps that behave differently under tmux (TERM, truecolor, key
+// sequences, passthrough escapes). Without tmux there is no session to attach
+// to, every browser that opens the ttyd URL starts its own copy of the
+// command, the exit status is not recorded and auto-reconnect is unavailable.
+func WithDirect(enabled bool) Option {
+	return func(c *config) {
+		c.direct = enabled
+	}
+}
+
+// WithPace sets the default pace of Type and SendKeys, so input is sent key
+// by key like a person typing. Defaults to zero, which sends input at once.
+func WithPace(pace Pace) Option {
+	return func(c *config) {
+		c.pace = pace
+	}
+}
+
+// WithKeyboardLayout sets the keyboard layout key combinations are typed on,
+// one of KeyboardLayouts, like "de", "fr" or "jp". SendKey then presses the
+// key that types a character on that layout, with shift or AltGr as needed, so
+// combinations like ctrl+ö or alt+@ reach the app with the same key codes as
+// from that keyboard. Text typed without modifiers does not depend on the
+// layout. Defaults to DefaultKeyboardLayout.
+func WithKeyboardLayout(layout string) Option {
+	return func(c *config) {
+		c.keyboardLayout = strings.ToLower(layout)
+	}
+}
@@ -0,0 +1,368 @@
+package terminal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// maxSequenceLen bounds the CSI sequences the output parser buffers. Longer
+// ones are skipped, since no sequence imprint follows needs more.
+const maxSequenceLen = 4096
+
+// tapReplyTimeout bounds how long the output tap takes to answer a query.
+const tapReplyTimeout = 2 * time.Second
+
+// maxOSCLen bounds the OSC sequences the output parser buffers, which carry
+// whole clipboard contents.
+const maxOSCLen = 1 << 20
+
+// sequence is an escape sequence from the app's output.
+type sequence struct {
+	kind byte   // '[' for CSI, ']' for OSC, 'c' for a full reset (RIS)
+	body string // Parameters and final byte of a CSI, or the payload of an OSC
+}
+
+// Parser states.
+const (
+	parseGround = iota
+	parseEscape
+	parseCSI
+	parseOSC
+	parseOSCEscape
+	parseSkip // Inside an overlong sequence, until it ends
+)
+
+// sequenceParser picks CSI and OSC sequences out of an output stream, which
+// may split them across reads.
+type sequenceParser struct {
+	state int
+	buf   []byte
+}
+
+// write parses p, calling emit for each complete sequence.
+func (p *sequenceParser) write(data []byte, emit func(sequence)) {
+	for len(data) > 0 {
+		if p.state == parseGround {
+			i := bytes.IndexByte(data, 0x1b)
+			if i < 0 {
+				return
+			}
+			data = data[i+1:]
+			p.state = parseEscape
+			continue
+		}
+
+		b := data[0]
+		data = data[1:]
+		switch p.state {
+		case parseEscape:
+			p.buf = p.buf[:0]
+			switch b {
+			case '[':
+				p.state = parseCSI
+			case ']':
+				p.state = parseOSC
+			case 'c':
+				emit(sequence{kind: 'c'})
+				p.state = parseGround
+			case 0x1b:
+				// Still at the start of a sequence
+			default:
+				p.state = parseGround
+			}
+		case parseCSI:
+			switch {
+			case b >= 0x40 && b <= 0x7e:
+				p.buf = append(p.buf, b)
+				emit(sequence{kind: '[', body: string(p.buf)})
+				p.state = parseGround
+			case b >= 0x20 && b <= 0x3f && len(p.buf) < maxSequenceLen:
+				p.buf = append(p.buf, b)
+			case b == 0x1b:
+				p.state = parseEscape
+			default:
+				p.state = parseGround
+			}
+		case parseOSC, parseSkip:
+			switch {
+			case b == 0x07:
+				if p.state == parseOSC {
+					emit(sequence{kind: ']', body: string(p.buf)})
+				}
+				p.state = parseGround
+			case b == 0x1b:
+				if p.state == parseOSC {
+					p.state = parseOSCEscape
+				} else {
+					p.state = parseEscape
+				}
+			case len(p.buf) >= maxOSCLen:
+				p.state = parseSkip
+			default:
+				p.buf = append(p.buf, b)
+			}
+		case parseOSCEscape:
+			if b == '\\' {
+				emit(sequence{kind: ']', body: string(p.buf)})
+				p.state = parseGround
+			} else {
+				// Any other escape aborts the OSC and starts a new sequence
+				p.state = parseEscape
+				data = append([]byte{b}, data...)
+			}
+		}
+	}
+}
+
+// csiParams splits a CSI body like ">4;2m" into its private prefix (one of
+// <=>?, or 0), its numeric parameters and its final byte. Missing or
+// malformed parameters are 0, and sub-parameters after : are ignored.
+func csiParams(body string) (prefix byte, params []int, final byte) {
+	final = body[len(body)-1]
+	body = body[:len(body)-1]
+	if body != "" && strings.IndexByte("<=>?", body[0]) >= 0 {
+		prefix, body = body[0], body[1:]
+	}
+	if body == "" {
+		return prefix, nil, final
+	}
+	for _, field := range strings.Split(body, ";") {
+		field, _, _ = strings.Cut(field, ":")
+		n, _ := strconv.Atoi(field)
+		params = append(params, n)
+	}
+	return prefix, params, final
+}
+
+// param returns params[i], or def if it is missing or 0.
+func param(params []int, i, def int) int {
+	if i < len(params) && params[i] > 0 {
+		return params[i]
+	}
+	return def
+}
+
+// appModes tracks the terminal modes the app has set that tmux does not
+// report, as seen in its output.
+type appModes struct {
+	kitty           []int // Kitty keyboard protocol flag stack; the last entry is in effect
+	modifyOtherKeys int
+	focusReporting  bool // DEC private mode 1004
+}
+
+// apply updates the modes for a sequence from the app's output.
+func (m *appModes) apply(seq sequence) {
+	if seq.kind == 'c' {
+		*m = appModes{}
+		return
+	}
+	if seq.kind != '[' {
+		return
+	}
+
+	prefix, params, final := csiParams(seq.body)
+	switch {
+	case prefix == '?' && (final == 'h' || final == 'l'):
+		if slices.Contains(params, 1004) {
+			m.focusReporting = final == 'h'
+		}
+	case final == 'u' && prefix == '>':
+		m.kitty = append(m.kitty, param(params, 0, 0))
+		if len(m.kitty) > kittyStackSize {
+			m.kitty = m.kitty[1:]
+		}
+	case final == 'u' && prefix == '<':
+		m.kitty = m.kitty[:max(len(m.kitty)-param(params, 0, 1), 0)]
+	case final == 'u' && prefix == '=':
+		flags, current := param(params, 0, 0), m.kittyFlags()
+		switch param(params, 1, 1) {
+		case 2:
+			flags = current | flags
+		case 3:
+			flags = current &^ flags
+		}
+		if len(m.kitty) == 0 {
+			m.kitty = append(m.kitty, flags)
+		} else {
+			m.kitty[len(m.kitty)-1] = flags
+		}
+	case final == 'm' && prefix == '>':
+		// CSI > 4 ; Pv m sets modifyOtherKeys; CSI > m resets every key modifier option
+		if param(params, 0, 0) == 4 {
+			m.modifyOtherKeys = param(params, 1, 0)
+		} else if len(params) == 0 {
+			m.modifyOtherKeys = 0
+		}
+	}
+}
+
+// reply returns the answer to a query in the app's output about the modes,
+// or nil. Only the kitty keyboard query (CSI ? u) is answered, as a
+// kitty-capable terminal would; tmux ignores it.
+func (m *appModes) reply(seq sequence) []byte {
+	if seq.kind != '[' || seq.body != "?u" {
+		return nil
+	}
+	return fmt.Appendf(nil, "\x1b[?%du", m.kittyFlags())
+}
+
+// kittyFlags returns the kitty keyboard protocol flags in effect.
+func (m *appModes) kittyFlags() int {
+	if len(m.kitty) == 0 {
+		return 0
+	}
+	return m.kitty[len(m.kitty)-1]
+}
+
+// outputTap follows the app's output in tmux mode. tmux handles many of the
+// app's mode changes itself without reporting them, so pipe-pane copies the
+// pane's output into a FIFO, where imprint parses the modes and the app's
+// clipboard writes out of it, and answers the app's keyboard protocol and
+// clipboard queries. Direct mode does both in xterm.js instead.
+type outputTap struct {
+	dir   string
+	fifo  *os.File
+	done  chan struct{}                                // Closed once the reader has stopped
+	reply func(ctx context.Context, data []byte) error // Sends a reply to the app as input
+
+	mu        sync.Mutex
+	parser    sequenceParser
+	modes     appModes
+	clipboard clipboardState
+	replies   [][]byte // Replies to queries parsed but not yet sent
+	err       error    // Why reading stopped early or the last reply failed
+}
+
+// newOutputTap creates the FIFO and starts reading it. reply sends the
+// answers to the app's queries.
+func newOutputTap(reply func(ctx context.Context, data []byte) error) (*outputTap, error) {
+	dir, err := os.MkdirTemp("", "imprint-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output tap: %w", err)
+	}
+	path := filepath.Join(dir, "output")
+	if err := syscall.Mkfifo(path, 0o600); err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to create output tap: %w", err)
+	}
+
+	// Opening read-write keeps the FIFO from blocking until tmux opens it,
+	// and from reaching EOF whenever the writer restarts
+	fifo, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to open output tap: %w", err)
+	}
+
+	o := &outputTap{dir: dir, fifo: fifo, done: make(chan struct{}), reply: reply}
+	go o.run()
+	return o, nil
+}
+
+// path returns the FIFO for tmux pipe-pane to write the pane's output to.
+func (o *outputTap) path() string {
+	return filepath.Join(o.dir, "output")
+}
+
+// run reads the pane's output until the tap is closed.
+func (o *outputTap) run() {
+	defer close(o.done)
+
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := o.fifo.Read(buf)
+		if n > 0 {
+			o.mu.Lock()
+			o.parser.write(buf[:n], o.apply)
+			replies := o.replies
+			o.replies = nil
+			o.mu.Unlock()
+			o.sendReplies(replies)
+		}
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				o.mu.Lock()
+				o.err = err
+				o.mu.Unlock()
+			}
+			return
+		}
+	}
+}
+
+// apply follows a sequence from the app's output, queueing any reply it
+// needs. Caller must hold o.mu.
+func (o *outputTap) apply(seq sequence) {
+	o.modes.apply(seq)
+	if reply := o.modes.reply(seq); reply != nil {
+		o.replies = append(o.replies, reply)
+	}
+	if reply := o.clipboard.apply(seq); reply != nil {
+		o.replies = append(o.replies, reply)
+	}
+}
+
+// sendReplies sends queued replies to the app, recording the first failure.
+func (o *outputTap) sendReplies(replies [][]byte) {
+	if o.reply == nil {
+		return
+	}
+	for _, data := range replies {
+		ctx, cancel := context.WithTimeout(context.Background(), tapReplyTimeout)
+		err := o.reply(ctx, data)
+		cancel()
+		if err != nil {
+			o.mu.Lock()
+			o.err = fmt.Errorf("failed to answer the app: %w", err)
+			o.mu.Unlock()
+			return
+		}
+	}
+}
+
+// keyboard returns the keyboard protocol the app has enabled.
+func (o *outputTap) keyboard() KeyboardProtocol {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return KeyboardProtocol{KittyFlags: o.modes.kittyFlags(), ModifyOtherKeys: o.modes.modifyOtherKeys}
+}
+
+// focusReporting reports whether the app has turned on focus reporting.
+func (o *outputTap) focusReporting() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.modes.focusReporting
+}
+
+// failure returns why the tap stopped following the output or last failed to
+// answer the app, or nil if neither has happened.
+func (o *outputTap) failure() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.err
+}
+
+// close stops reading and removes the FIFO.
+func (o *outputTap) close() {
+	o.fifo.Close()
+	<-o.done
+	os.RemoveAll(o.dir)
+}
+
+// stopTapUnlocked closes the output tap, if there is one. Caller must hold the lock.
+func (t *Terminal) stopTapUnlocked() {
+	if t.tap != nil {
+		t.tap.close()
+		t.tap = nil
+	}
+}
@@ -0,0 +1,62 @@
+package terminal
+
+import "testing"
+
+func TestSequenceParser(t *testing.T) {
+	var got []sequence
+	var parser sequenceParser
+	for _, chunk := range []string{"a\x1b[>1", "u\x1b]0;ti", "tle\x1b\\\x1b", "cb\x1b]2;x\x07"} {
+		parser.write([]byte(chunk), func(seq sequence) { got = append(got, seq) })
+	}
+	want := []sequence{{'[', ">1u"}, {']', "0;title"}, {'c', ""}, {']', "2;x"}}
+	if len(got) != len(want) {
+		t.Fatalf("parsed %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("sequence %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestAppModes(t *testing.T) {
+	var modes appModes
+	var parser sequenceParser
+	for _, chunk := range []string{"\x1b[>1", "u\x1b[>5u\x1b[=2;3u", "\x1b]0;t\x07\x1b[>4;2m", "\x1b[<u"} {
+		parser.write([]byte(chunk), modes.apply)
+	}
+	if modes.kittyFlags() != 1 || modes.modifyOtherKeys != 2 {
+		t.Errorf("after push 1, push 5, clear 2, modifyOtherKeys 2 and pop: flags %d, modifyOtherKeys %d; want 1, 2",
+			modes.kittyFlags(), modes.modifyOtherKeys)
+	}
+
+	parser.write([]byte("\x1b[?1004h"), modes.apply)
+	if !modes.focusReporting {
+		t.Error("focus reporting off after ESC[?1004h")
+	}
+	parser.write([]byte("\x1b[?25;1004l"), modes.apply)
+	if modes.focusReporting {
+		t.Error("focus reporting on after ESC[?25;1004l")
+	}
+
+	parser.write([]byte("\x1b[>1u\x1b[?1004h\x1bc"), modes.apply)
+	if modes.kittyFlags() != 0 || modes.modifyOtherKeys != 0 || modes.focusReporting {
+		t.Errorf("modes after a full reset = %+v, want none", modes)
+	}
+}
+
+func TestKittyQueryReply(t *testing.T) {
+	var tap outputTap
+	var parser sequenceParser
+	parser.write([]byte("\x1b[?u\x1b[>5u\x1b[?"), tap.apply)
+	parser.write([]byte("u\x1b[?1u"), tap.apply)
+	want := []string{"\x1b[?0u", "\x1b[?5u"}
+	if len(tap.replies) != len(want) {
+		t.Fatalf("replies = %q, want %q", tap.replies, want)
+	}
+	for i := range want {
+		if string(tap.replies[i]) != want[i] {
+			t.Errorf("reply %d = %q, want %q", i, tap.replies[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package terminal
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Pace spaces out key-by-key input to mimic a person typing, which surfaces
+// races that input sent all at once hides, such as debounced search fields
+// or apps that read a partial escape sequence.
+type Pace struct {
+	Delay  time.Duration // Pause between keys
+	Jitter time.Duration // Up to this much extra random pause per key
+}
+
+// IsZero reports whether the pace sends input without pausing.
+func (p Pace) IsZero() bool {
+	return p.Delay <= 0 && p.Jitter <= 0
+}
+
+// wait pauses for one key's delay plus a random share of the jitter.
+func (p Pace) wait(ctx context.Context) error {
+	d := p.Delay
+	if p.Jitter > 0 {
+		d += rand.N(p.Jitter + 1)
+	}
+	if d <= 0 {
+		return nil
+	}
+	return sleep(ctx, d)
+}
+
+// Pace returns the default pace of Type and SendKeys.
+func (t *Terminal) Pace() Pace {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.pace
+}
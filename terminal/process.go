@@ -0,0 +1,98 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProcessStatus describes the command running in the terminal's tmux pane.
+type ProcessStatus struct {
+	Running  bool          // Whether the command is still running
+	PID      int           // Process ID of the command
+	ExitCode int           // Exit code if the command exited normally, otherwise -1
+	Signal   int           // Signal that terminated the command, otherwise 0
+	Runtime  time.Duration // How long the command ran (second resolution once exited), or has been running so far
+}
+
+// ProcessStatus reports whether the command under test is still running and,
+// once it has exited, how it exited. Panes are kept after exit via tmux's
+// remain-on-exit, so a clean quit can be told apart from a crash.
+//
+// tmux only records the exit status once it has reaped the process; until
+// then an exited command reports ExitCode -1 and Signal 0. In direct mode the
+// exit status is never recorded, so an exited command always reports -1 and 0.
+func (t *Terminal) ProcessStatus(ctx context.Context) (ProcessStatus, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.page == nil {
+		return ProcessStatus{}, fmt.Errorf("terminal not ready")
+	}
+
+	if t.direct {
+		status := ProcessStatus{ExitCode: -1}
+		if pid, err := t.directPID(ctx); err == nil {
+			status.Running = true
+			status.PID = pid
+			status.Runtime = time.Since(t.startedAt)
+		}
+		return status, nil
+	}
+
+	out, err := t.tmux(ctx, "list-panes", "-t", t.tmuxSession,
+		"-F", "#{pane_dead}|#{pane_pid}|#{pane_dead_status}|#{pane_dead_signal}|#{pane_dead_time}").Output()
+	if err != nil {
+		return ProcessStatus{}, fmt.Errorf("failed to query tmux pane: %w", err)
+	}
+
+	fields := strings.Split(strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0]), "|")
+	if len(fields) != 5 {
+		return ProcessStatus{}, fmt.Errorf("unexpected tmux pane status: %q", out)
+	}
+
+	status := ProcessStatus{
+		Running:  fields[0] != "1",
+		ExitCode: -1,
+	}
+	status.PID, _ = strconv.Atoi(fields[1])
+
+	if status.Running {
+		status.Runtime = time.Since(t.startedAt)
+		return status, nil
+	}
+
+	if code, err := strconv.Atoi(fields[2]); err == nil {
+		status.ExitCode = code
+	}
+	if signal, err := strconv.Atoi(fields[3]); err == nil {
+		status.Signal = signal
+	}
+	if deadTime, err := strconv.ParseInt(fields[4], 10, 64); err == nil {
+		// tmux reports the exit time in whole seconds
+		status.Runtime = max(time.Unix(deadTime, 0).Sub(t.startedAt.Truncate(time.Second)), 0)
+	}
+
+	return status, nil
+}
+
+// directPID returns the PID of the command ttyd runs in direct mode. ttyd
+// starts a copy of the command for each connected browser; the oldest one
+// belongs to imprint's own page. Caller must hold at least the read lock.
+func (t *Terminal) directPID(ctx context.Context) (int, error) {
+	if t.cmd == nil || t.cmd.Process == nil {
+		return 0, fmt.Errorf("ttyd not running")
+	}
+	out, err := exec.CommandContext(ctx, "pgrep", "-o", "-P", strconv.Itoa(t.cmd.Process.Pid)).Output()
+	if err != nil {
+		return 0, fmt.Errorf("command not running")
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return 0, fmt.Errorf("unexpected pgrep output: %q", out)
+	}
+	return pid, nil
+}
@@ -0,0 +1,67 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// rawChunkSize bounds how many bytes go into a single tmux send-keys call.
+const rawChunkSize = 512
+
+// SendRaw writes data to the app's PTY exactly as given, without going through
+// key handling: control bytes, lone escapes, NUL and invalid UTF-8 all arrive
+// unchanged. Unlike Type and SendKey, which model what a keyboard can produce
+// (and reject non-printable text), SendRaw is for input no key would send,
+// such as unmodelled CSI sequences.
+//
+// With tmux the bytes go straight to the pane with send-keys -H; in direct mode
+// they are sent through xterm.js as binary input, which ttyd writes to the PTY.
+func (t *Terminal) SendRaw(ctx context.Context, data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("no bytes to send")
+	}
+	return t.sendRawUnlocked(ctx, data)
+}
+
+// sendRawUnlocked writes data to the PTY. Caller must hold the lock.
+func (t *Terminal) sendRawUnlocked(ctx context.Context, data []byte) error {
+	if t.direct {
+		codes := make([]int, len(data))
+		for i, b := range data {
+			codes[i] = int(b)
+		}
+		_, err := t.page.Context(ctx).Eval(`(codes) => {
+			window.term._core.coreService.triggerBinaryEvent(String.fromCharCode(...codes));
+		}`, codes)
+		if err != nil {
+			return fmt.Errorf("failed to send raw bytes: %w", err)
+		}
+		return nil
+	}
+
+	return t.sendPaneInput(ctx, t.tmuxSession, data)
+}
+
+// sendPaneInput writes data to a tmux session's pane as input, with
+// send-keys -H. It needs only the config, so the output tap can answer the
+// app's queries without the Terminal's lock.
+func (c *config) sendPaneInput(ctx context.Context, session string, data []byte) error {
+	for start := 0; start < len(data); start += rawChunkSize {
+		chunk := data[start:min(start+rawChunkSize, len(data))]
+		args := []string{"send-keys", "-t", session, "-H"}
+		for _, b := range chunk {
+			args = append(args, fmt.Sprintf("%02x", b))
+		}
+		if out, err := exec.CommandContext(ctx, "tmux", c.tmuxArgs(args...)...).CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to send raw bytes: %w: %s", err, out)
+		}
+	}
+	return nil
+}
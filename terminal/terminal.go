@@ -0,0 +1,957 @@
+// Package terminal drives a real terminal session through ttyd, tmux and
+// headless Chrome. It is the engine behind the imprint MCP server and can be
+// used directly from Go programs, for example in integration tests:
+//
+//	term, err := terminal.New(terminal.WithArgs("./my-tui"), terminal.WithSize(30, 120))
+//	if err != nil {
+//		return err
+//	}
+//	if err := term.Start(ctx); err != nil {
+//		return err
+//	}
+//	defer term.Close()
+//
+//	term.SendKeys(ctx, []string{"down", "enter"})
+//	term.WaitForText(ctx, "Done", 5000)
+package terminal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/rivo/uniseg"
+)
+
+// Terminal manages a real terminal session via ttyd and headless Chrome.
+type Terminal struct {
+	config
+
+	mu          sync.RWMutex
+	browser     *rod.Browser
+	page        *rod.Page
+	cmd         *exec.Cmd
+	exited      chan struct{} // Closed once the ttyd process has exited
+	port        int
+	tmuxSession string      // Unique tmux session name for session sharing
+	startedAt   time.Time   // When the pane process was first seen running
+	heldKeys    []input.Key // Keys pressed by KeyDown and not yet released, in press order
+	shiftedFor  []input.Key // Held keys whose names, like A or !, made KeyDown press shift
+	blurred     bool        // Set by Blur until Focus
+	tap         *outputTap  // Follows the app's output in tmux mode; nil in direct mode
+
+	keyboardOverride *KeyboardProtocol // Set by SetKeyboardProtocol; nil follows the app
+
+	stopHealth chan struct{} // Closed to stop the health supervisor
+	healthMu   sync.Mutex    // Guards health, which the supervisor updates without holding mu
+	health     Health
+}
+
+// DefaultStartupTimeout bounds how long Start and Restart wait for the terminal to become ready.
+const DefaultStartupTimeout = 30 * time.Second
+
+// readinessPollInterval is how often startup readiness probes are retried.
+const readinessPollInterval = 50 * time.Millisecond
+
+// errStartupTimeout is the cancellation cause when the startup timeout elapses.
+var errStartupTimeout = errors.New("startup timeout elapsed")
+
+// New creates a new Terminal instance. Call Start to launch it.
+func New(opts ...Option) (*Terminal, error) {
+	cfg := defaultConfig()
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	t := &Terminal{config: cfg}
+
+	port, err := findFreePort()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find free port: %w", err)
+	}
+	t.port = port
+	t.tmuxSession = fmt.Sprintf("imprint_%d", port)
+
+	return t, nil
+}
+
+// findFreePort returns an available TCP port.
+func findFreePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// Start launches the terminal session.
+func (t *Terminal) Start(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.startUnlocked(ctx)
+}
+
+// startUnlocked launches the terminal session without acquiring the lock.
+// Caller must hold the lock.
+//
+// Startup is gated on readiness probes rather than fixed sleeps: ttyd must
+// accept connections, xterm.js must be attached to the page, and the tmux pane
+// must have a live process. All probes share the startup timeout.
+func (t *Terminal) startUnlocked(ctx context.Context) error {
+	ctx, cancel := context.WithTimeoutCause(ctx, t.startupTimeout, errStartupTimeout)
+	defer cancel()
+
+	if !t.direct {
+		t.stopTapUnlocked()
+		// The tap answers the app's queries by typing the reply into the
+		// pane, from its own goroutine, so it gets copies of what it needs
+		cfg, session := t.config, t.tmuxSession
+		tap, err := newOutputTap(func(ctx context.Context, data []byte) error {
+			return cfg.sendPaneInput(ctx, session, data)
+		})
+		if err != nil {
+			return err
+		}
+		t.tap = tap
+		if err := t.startSessionUnlocked(ctx); err != nil {
+			return err
+		}
+	}
+	if err := t.startTtydUnlocked(ctx); err != nil {
+		return err
+	}
+	if err := t.launchBrowserUnlocked(); err != nil {
+		return err
+	}
+	if err := t.openPageUnlocked(ctx); err != nil {
+		return err
+	}
+	if err := t.waitForPane(ctx); err != nil {
+		return err
+	}
+
+	t.startSupervisorUnlocked()
+	return nil
+}
+
+// startTtydUnlocked starts ttyd and waits until it accepts connections.
+// Caller must hold the lock.
+func (t *Terminal) startTtydUnlocked(ctx context.Context) error {
+	args := []string{
+		"--port", fmt.Sprintf("%d", t.port),
+		"--interface", "127.0.0.1",
+		"--writable",
+	}
+
+	if t.direct {
+		// ttyd runs the command itself, which inherits ttyd's environment and
+		// directory. ttyd sizes the PTY from the page's initial grid, so set the
+		// requested size before the command starts.
+		argv := append([]string{"sh", "-c", fmt.Sprintf(`stty rows %d cols %d 2>/dev/null; exec "$@"`, t.rows, t.cols), "sh"}, t.commandArgv()...)
+		t.cmd = exec.Command("ttyd", append(args, argv...)...)
+		t.cmd.Dir = t.dir
+		if len(t.env) > 0 {
+			t.cmd.Env = os.Environ()
+			for _, key := range slices.Sorted(maps.Keys(t.env)) {
+				t.cmd.Env = append(t.cmd.Env, key+"="+t.env[key])
+			}
+		}
+	} else {
+		// Attach to the session started by startSessionUnlocked for session sharing
+		// The -2 flag forces 256-color mode for consistent colors across terminals
+		args = append(args, "tmux", "-2")
+		args = append(args, t.tmuxArgs("attach-session", "-t", t.tmuxSession)...)
+		t.cmd = exec.Command("ttyd", args...)
+	}
+
+	if err := t.cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ttyd: %w", err)
+	}
+
+	exited := make(chan struct{})
+	t.exited = exited
+	go func(cmd *exec.Cmd) {
+		cmd.Wait()
+		close(exited)
+	}(t.cmd)
+
+	// Wait for ttyd to be ready
+	return t.waitForPort(ctx)
+}
+
+// launchBrowserUnlocked launches headless Chrome and connects to it.
+// Caller must hold the lock.
+func (t *Terminal) launchBrowserUnlocked() error {
+	url, err := launcher.New().Headless(true).Launch()
+	if err != nil {
+		return fmt.Errorf("failed to launch browser: %w", err)
+	}
+	browser := rod.New().ControlURL(url)
+	if err := browser.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to browser: %w", err)
+	}
+	t.browser = browser
+	return nil
+}
+
+// openPageUnlocked navigates a new browser page to ttyd and waits for xterm.js.
+// Caller must hold the lock.
+func (t *Terminal) openPageUnlocked(ctx context.Context) error {
+	page, err := t.browser.Page(proto.TargetCreateTarget{})
+	if err != nil {
+		return fmt.Errorf("failed to open terminal page: %w", err)
+	}
+	t.page = page
+	t.heldKeys = nil // A new page starts with no keys down, and focused
+	t.shiftedFor = nil
+	t.blurred = false
+
+	if _, err := page.EvalOnNewDocument(fitGuardScript); err != nil {
+		return fmt.Errorf("failed to prepare terminal page: %w", err)
+	}
+	if t.direct {
+		if _, err := page.EvalOnNewDocument(termHookScript(keyboardHook, clipboardHook)); err != nil {
+			return fmt.Errorf("failed to prepare terminal page: %w", err)
+		}
+	}
+	if err := page.Context(ctx).Navigate(fmt.Sprintf("http://127.0.0.1:%d", t.port)); err != nil {
+		return fmt.Errorf("failed to open terminal page: %w", err)
+	}
+
+	// Wait for terminal to initialize, then give it the configured size
+	if err := t.waitForXterm(ctx); err != nil {
+		return err
+	}
+	return t.applyGeometryUnlocked(ctx, t.rows, t.cols)
+}
+
+// termHookScript returns a script that runs each hook on the xterm.js
+// terminal as ttyd creates it, before any output arrives, so the hooks see all
+// of the app's output. Each hook is a JavaScript function taking the terminal.
+func termHookScript(hooks ...string) string {
+	return `(() => {
+	const hooks = [` + strings.Join(hooks, ",\n") + `];
+	let current;
+	Object.defineProperty(window, 'term', {
+		configurable: true,
+		get: () => current,
+		set: (term) => {
+			current = term;
+			if (term && term.parser) hooks.forEach((hook) => hook(term));
+		},
+	});
+})();`
+}
+
+// commandArgv returns the argv that runs the configured command.
+func (c *config) commandArgv() []string {
+	if len(c.args) > 0 {
+		return c.args
+	}
+
+	// Check if this is a shell path (like /bin/zsh) or a complex command
+	if strings.HasPrefix(c.shell, "/") && !strings.Contains(c.shell, " ") {
+		// Simple shell path - run as interactive login shell
+		return []string{c.shell, "-l", "-i"}
+	}
+	// Complex command - wrap in sh -c for proper shell syntax handling
+	return []string{"sh", "-c", c.shell}
+}
+
+// waitForPort polls until ttyd accepts TCP connections on its port.
+func (t *Terminal) waitForPort(ctx context.Context) error {
+	addr := fmt.Sprintf("127.0.0.1:%d", t.port)
+	return t.poll(ctx, fmt.Sprintf("ttyd to accept connections on %s", addr), func() (bool, error) {
+		select {
+		case <-t.exited:
+			return false, fmt.Errorf("ttyd exited: %v", t.cmd.ProcessState)
+		default:
+		}
+
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return false, nil
+		}
+		conn.Close()
+		return true, nil
+	})
+}
+
+// waitForXterm polls until ttyd's page has created window.term and attached it to the DOM.
+func (t *Terminal) waitForXterm(ctx context.Context) error {
+	page := t.page.Context(ctx)
+	return t.poll(ctx, "xterm.js to attach in the browser page", func() (bool, error) {
+		result, err := page.Eval(`() => {
+			const term = window.term;
+			return !!(term && term.element && term.element.isConnected);
+		}`)
+		if err != nil {
+			return false, nil
+		}
+		return result.Value.Bool(), nil
+	})
+}
+
+// waitForPane polls until the tmux pane exists and its process has been spawned.
+// A command that already exited still counts: its pane is kept by
+// remain-on-exit and its exit status is available via ProcessStatus.
+// In direct mode it waits for ttyd to spawn the command, which only happens
+// once the page's websocket connects, so this runs last.
+func (t *Terminal) waitForPane(ctx context.Context) error {
+	if t.direct {
+		return t.poll(ctx, "ttyd to start the command", func() (bool, error) {
+			if _, err := t.directPID(ctx); err != nil {
+				return false, nil
+			}
+			t.startedAt = time.Now()
+			return true, nil
+		})
+	}
+
+	return t.poll(ctx, fmt.Sprintf("tmux session %s to run a live process", t.tmuxSession), func() (bool, error) {
+		out, err := t.tmux(ctx, "list-panes", "-t", t.tmuxSession, "-F", "#{pane_pid}").Output()
+		if err != nil {
+			return false, nil
+		}
+		var pid int
+		if _, err := fmt.Sscan(string(out), &pid); err != nil || pid <= 0 {
+			return false, nil
+		}
+		t.startedAt = time.Now()
+		return true, nil
+	})
+}
+
+// poll calls check every readinessPollInterval until it reports done. An error
+// from check aborts immediately; if ctx ends first, the error describes what
+// was still being waited for.
+func (t *Terminal) poll(ctx context.Context, what string, check func() (bool, error)) error {
+	ticker := time.NewTicker(readinessPollInterval)
+	defer ticker.Stop()
+
+	for {
+		done, err := check()
+		if err != nil {
+			return fmt.Errorf("terminal failed to start while waiting for %s: %w", what, err)
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(context.Cause(ctx), errStartupTimeout) {
+				return fmt.Errorf("terminal not ready after %v: timed out waiting for %s", t.startupTimeout, what)
+			}
+			return fmt.Errorf("cancelled while waiting for %s: %w", what, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendKey sends a keystroke to the terminal.
+func (t *Terminal) SendKey(ctx context.Context, key string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return err
+	}
+
+	return t.sendKeyUnlocked(ctx, key)
+}
+
+// sendKeyUnlocked sends a keystroke without acquiring the lock.
+// Caller must hold the lock.
+//
+// Keys that the app's keyboard protocol (kitty or modifyOtherKeys) encodes
+// differently are sent as raw bytes in that encoding. Other keys take one of
+// three paths:
+//  1. Literal control characters (\n, \r, \t, space) → keyboard simulation
+//  2. Single printable graphemes → xterm's term.input() for Unicode/emoji support
+//  3. Named keys and modifier combos → keyboard simulation via keyMap/characterKeyMap
+func (t *Terminal) sendKeyUnlocked(ctx context.Context, key string) error {
+	if key == "" {
+		return fmt.Errorf("key cannot be empty")
+	}
+
+	rawKey := key
+
+	protocol, err := t.keyboardUnlocked(ctx)
+	if err != nil {
+		return err
+	}
+	if data, ok := encodeProtocolKey(rawKey, protocol); ok {
+		if err := t.sendRawUnlocked(ctx, data); err != nil {
+			return fmt.Errorf("failed to send key %q: %w", rawKey, err)
+		}
+		return nil
+	}
+
+	// Path 1: Literal control character aliases
+	switch rawKey {
+	case "\n", "\r":
+		if err := t.pressKey(ctx, input.Enter); err != nil {
+			return fmt.Errorf("failed to send enter: %w", err)
+		}
+		return nil
+	case "\t":
+		if err := t.pressKey(ctx, input.Tab); err != nil {
+			return fmt.Errorf("failed to send tab: %w", err)
+		}
+		return nil
+	case " ":
+		if err := t.pressKey(ctx, input.Space); err != nil {
+			return fmt.Errorf("failed to send space: %w", err)
+		}
+		return nil
+	}
+
+	if !utf8.ValidString(rawKey) {
+		return fmt.Errorf("invalid utf-8 key: %q", rawKey)
+	}
+
+	// Path 2: Single printable graphemes (Unicode, emoji, combined characters)
+	// These bypass keyboard simulation because they can't be mapped to physical keys.
+	// Using xterm's term.input() API writes directly to the terminal buffer.
+	if isSingleGrapheme(rawKey) {
+		if !isPrintableString(rawKey) {
+			return fmt.Errorf("non-printable key: %q", rawKey)
+		}
+
+		_, err := t.page.Context(ctx).Eval(fmt.Sprintf(`() => {
+			const term = window.term;
+			if (!term || typeof term.input !== 'function') {
+				throw new Error("terminal not initialized");
+			}
+			term.input(%q);
+		}`, rawKey))
+		if err != nil {
+			return fmt.Errorf("failed to send key %q: %w", rawKey, err)
+		}
+		return nil
+	}
+
+	// Path 3: Named keys and modifier combinations like "ctrl+c", "ctrl+shift+tab".
+	// Ctrl with punctuation makes a control character that xterm.js does not
+	// always encode, so those go in as input like Path 2.
+	if c, ok := controlCharacter(rawKey); ok {
+		if err := t.inputUnlocked(ctx, string(rune(c))); err != nil {
+			return fmt.Errorf("failed to send key %q: %w", rawKey, err)
+		}
+		return nil
+	}
+	// Characters with modifiers are typed on the configured keyboard layout
+	if char, lk, modifiers, ok, err := t.layoutCombo(rawKey); err != nil {
+		return err
+	} else if ok {
+		if err := t.pressKeyEvent(ctx, layoutKeyEncoder(char, lk), modifiers...); err != nil {
+			return fmt.Errorf("failed to send key %q: %w", rawKey, err)
+		}
+		return nil
+	}
+	target, modifiers, err := parseKeyCombo(rawKey)
+	if err != nil {
+		return err
+	}
+	if err := t.pressKey(ctx, target, modifiers...); err != nil {
+		return fmt.Errorf("failed to send key %q: %w", rawKey, err)
+	}
+	return nil
+}
+
+// isPrintableString reports whether s has only printable characters. SendKey
+// uses it to reject control characters, which no key produces on its own;
+// SendRaw is the way to send those.
+func isPrintableString(s string) bool {
+	for _, r := range s {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isSingleGrapheme(s string) bool {
+	graphemes := uniseg.NewGraphemes(s)
+	count := 0
+	for graphemes.Next() {
+		count++
+		if count > 1 {
+			return false
+		}
+	}
+	return count == 1
+}
+
+// SendKeys sends multiple keystrokes to the terminal at the default pace
+// (see WithPace). Fails fast on the first error, returning which key failed.
+func (t *Terminal) SendKeys(ctx context.Context, keys []string) error {
+	return t.SendKeysPaced(ctx, keys, t.Pace())
+}
+
+// SendKeysPaced sends multiple keystrokes to the terminal, pausing between
+// them as pace says. Fails fast on the first error, returning which key failed.
+// When paced, the terminal is only locked to send each key, so screenshots and
+// reads go on during the pauses.
+func (t *Terminal) SendKeysPaced(ctx context.Context, keys []string, pace Pace) error {
+	if pace.IsZero() {
+		return t.sendKeysFrom(ctx, keys, 0)
+	}
+
+	for i := range keys {
+		if i > 0 {
+			if err := pace.wait(ctx); err != nil {
+				return err
+			}
+		}
+		if err := t.sendKeysFrom(ctx, keys[i:i+1], i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendKeysFrom sends keys under one lock, numbering them from first in errors.
+func (t *Terminal) sendKeysFrom(ctx context.Context, keys []string, first int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return err
+	}
+
+	for i, key := range keys {
+		if err := t.sendKeyUnlocked(ctx, key); err != nil {
+			return fmt.Errorf("key %d (%s): %w", first+i, key, err)
+		}
+	}
+	return nil
+}
+
+// pressKey presses the modifiers in order, types key, then releases the
+// modifiers in reverse order. Events are dispatched on a page bound to ctx so a
+// cancelled request stops between events, while releases always go out so no
+// key is left held down in the browser. Keys held by KeyDown stay held, and
+// their modifiers apply to key too.
+func (t *Terminal) pressKey(ctx context.Context, key input.Key, modifiers ...input.Key) error {
+	return t.pressKeyEvent(ctx, key.Encode, modifiers...)
+}
+
+// pressKeyEvent is pressKey for a key whose events encode builds, for keys
+// that go-rod's key table cannot describe, like those of other layouts.
+func (t *Terminal) pressKeyEvent(ctx context.Context, encode func(proto.InputDispatchKeyEventType, int) *proto.InputDispatchKeyEvent, modifiers ...input.Key) (err error) {
+	page := t.page.Context(ctx)
+	held := t.heldModifiers()
+	var pressed []input.Key
+
+	defer func() {
+		for i := len(pressed) - 1; i >= 0; i-- {
+			held &^= pressed[i].Modifier()
+			releaseErr := pressed[i].Encode(proto.InputDispatchKeyEventTypeKeyUp, held).Call(t.page)
+			if err == nil {
+				err = releaseErr
+			}
+		}
+	}()
+
+	for _, modifier := range modifiers {
+		if held&modifier.Modifier() != 0 {
+			continue
+		}
+		held |= modifier.Modifier()
+		if err := modifier.Encode(proto.InputDispatchKeyEventTypeKeyDown, held).Call(page); err != nil {
+			return err
+		}
+		pressed = append(pressed, modifier)
+	}
+
+	if err := encode(proto.InputDispatchKeyEventTypeKeyDown, held).Call(page); err != nil {
+		return err
+	}
+	return encode(proto.InputDispatchKeyEventTypeKeyUp, held).Call(t.page)
+}
+
+// sleep pauses for d, returning early with the context's error if ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Type types a string of characters at the default pace (see WithPace).
+func (t *Terminal) Type(ctx context.Context, text string) error {
+	return t.TypePaced(ctx, text, t.Pace())
+}
+
+// TypePaced types a string of characters. With a zero pace the whole string
+// is sent at once; otherwise it is sent one grapheme at a time, pausing
+// between them as pace says, with the terminal only locked to send each one.
+func (t *Terminal) TypePaced(ctx context.Context, text string, pace Pace) error {
+	if pace.IsZero() {
+		return t.input(ctx, text)
+	}
+
+	graphemes := uniseg.NewGraphemes(text)
+	for first := true; graphemes.Next(); first = false {
+		if !first {
+			if err := pace.wait(ctx); err != nil {
+				return err
+			}
+		}
+		if err := t.input(ctx, graphemes.Str()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// input writes text to xterm.js as if typed.
+func (t *Terminal) input(ctx context.Context, text string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return err
+	}
+	return t.inputUnlocked(ctx, text)
+}
+
+// inputUnlocked writes text to xterm.js as if typed. Caller must hold the lock.
+func (t *Terminal) inputUnlocked(ctx context.Context, text string) error {
+	// Write directly to xterm.js via term.input() instead of DOM events.
+	//
+	// xterm.js ignores DOM InputEvents when its internal _keyDownSeen flag is set.
+	// Since SendKey() triggers keyboard events that may leave this flag in an
+	// unpredictable state, Type() must bypass the DOM entirely. The term.input()
+	// API writes directly to xterm's input buffer, avoiding this constraint.
+	_, err := t.page.Context(ctx).Eval(fmt.Sprintf(`() => {
+		const term = window.term;
+		if (!term || typeof term.input !== 'function') {
+			throw new Error("terminal not initialized");
+		}
+		term.input(%q);
+	}`, text))
+
+	return err
+}
+
+// Paste pastes text through xterm's paste path, like a user pasting from the
+// clipboard: line breaks are sent as carriage returns and, if the app has
+// enabled bracketed paste mode, the text is wrapped in ESC[200~ and ESC[201~ so
+// the app can tell it apart from typing. It reports whether bracketed paste
+// mode was active.
+func (t *Terminal) Paste(ctx context.Context, text string) (bracketed bool, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return false, err
+	}
+
+	result, err := t.page.Context(ctx).Eval(`(text) => {
+		const term = window.term;
+		if (!term || typeof term.paste !== 'function') {
+			throw new Error("terminal not initialized");
+		}
+		const modes = term.modes || term._core.coreService.decPrivateModes;
+		const bracketed = !!modes.bracketedPasteMode;
+		term.paste(text);
+		return bracketed;
+	}`, text)
+	if err != nil {
+		return false, fmt.Errorf("failed to paste: %w", err)
+	}
+	return result.Value.Bool(), nil
+}
+
+// Screenshot captures the current screen as JPEG with the specified quality (0-100).
+func (t *Terminal) Screenshot(ctx context.Context, quality int) ([]byte, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return nil, err
+	}
+
+	return t.page.Context(ctx).Screenshot(false, &proto.PageCaptureScreenshot{
+		Format:  proto.PageCaptureScreenshotFormatJpeg,
+		Quality: &quality,
+	})
+}
+
+// GetText returns the current screen as plain text.
+func (t *Terminal) GetText(ctx context.Context) (string, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return "", err
+	}
+
+	// Use xterm.js buffer API to get terminal content
+	result, err := t.page.Context(ctx).Eval(`() => {
+		const term = window.term;
+		if (!term) return "";
+
+		const buffer = term.buffer.active;
+		const lines = [];
+		for (let i = 0; i < buffer.length; i++) {
+			lines.push(buffer.getLine(i).translateToString().trimEnd());
+		}
+		return lines.join("\n");
+	}`)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to get terminal text: %w", err)
+	}
+
+	return result.Value.String(), nil
+}
+
+// WaitForText polls until the specified text appears on screen or timeout.
+// Returns elapsed time in ms, whether text was found, and any error.
+// Returns the context's error if ctx is done before either happens.
+func (t *Terminal) WaitForText(ctx context.Context, text string, timeoutMs int) (elapsedMs int, found bool, err error) {
+	pollInterval := 100 * time.Millisecond
+	startTime := time.Now()
+	timeoutDuration := time.Duration(timeoutMs) * time.Millisecond
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		screenText, err := t.GetText(ctx)
+		if err != nil {
+			return int(time.Since(startTime).Milliseconds()), false, fmt.Errorf("failed to get screen text: %w", err)
+		}
+
+		if strings.Contains(screenText, text) {
+			return int(time.Since(startTime).Milliseconds()), true, nil
+		}
+
+		elapsed := time.Since(startTime)
+		if elapsed >= timeoutDuration {
+			return int(elapsed.Milliseconds()), false, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return int(time.Since(startTime).Milliseconds()), false, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// WaitForStable polls until the screen stops changing for stableMs duration or timeout.
+// Returns elapsed time in ms, whether stability was achieved, and any error.
+// Returns the context's error if ctx is done before either happens.
+func (t *Terminal) WaitForStable(ctx context.Context, timeoutMs, stableMs int) (elapsedMs int, stable bool, err error) {
+	pollInterval := 100 * time.Millisecond
+	timeout := time.Duration(timeoutMs) * time.Millisecond
+	stableDuration := time.Duration(stableMs) * time.Millisecond
+
+	startTime := time.Now()
+	var lastText string
+	var lastChangeTime time.Time
+
+	lastText, err = t.GetText(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	lastChangeTime = startTime
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			elapsed := int(time.Since(startTime).Milliseconds())
+			return elapsed, false, ctx.Err()
+		case <-ticker.C:
+			currentText, err := t.GetText(ctx)
+			if err != nil {
+				elapsed := int(time.Since(startTime).Milliseconds())
+				return elapsed, false, err
+			}
+
+			if currentText != lastText {
+				lastText = currentText
+				lastChangeTime = time.Now()
+			}
+
+			timeSinceChange := time.Since(lastChangeTime)
+			if timeSinceChange >= stableDuration {
+				elapsed := int(time.Since(startTime).Milliseconds())
+				return elapsed, true, nil
+			}
+
+			if time.Since(startTime) >= timeout {
+				elapsed := int(time.Since(startTime).Milliseconds())
+				return elapsed, false, nil
+			}
+		}
+	}
+}
+
+// Resize changes the terminal dimensions. The tmux window, the xterm grid and
+// the page viewport are resized together, so the app gets a single SIGWINCH
+// and screenshots show exactly the new grid. It returns an error if the app's
+// PTY does not report the new size afterwards.
+func (t *Terminal) Resize(ctx context.Context, rows, cols int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.checkReadyUnlocked(); err != nil {
+		return err
+	}
+	if rows < 1 || cols < 1 {
+		return fmt.Errorf("invalid terminal size %dx%d", rows, cols)
+	}
+
+	// tmux keeps its window at a manual size, so the app only sees this resize
+	if !t.direct {
+		err := t.tmux(ctx, "resize-window", "-t", t.tmuxSession, "-x", strconv.Itoa(cols), "-y", strconv.Itoa(rows)).Run()
+		if err != nil {
+			return fmt.Errorf("failed to resize tmux window: %w", err)
+		}
+	}
+
+	if err := t.applyGeometryUnlocked(ctx, rows, cols); err != nil {
+		return err
+	}
+
+	t.rows = rows
+	t.cols = cols
+
+	return t.verifyPTYSizeUnlocked(ctx, rows, cols)
+}
+
+// Close terminates the terminal session.
+func (t *Terminal) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stopSupervisorUnlocked()
+
+	// Close browser (errors ignored: it may already have crashed)
+	if t.browser != nil {
+		t.browser.Close()
+	}
+
+	// Kill ttyd process
+	if t.cmd != nil && t.cmd.Process != nil {
+		t.cmd.Process.Kill()
+		<-t.exited
+	}
+
+	// Kill tmux session
+	if t.tmuxSession != "" && !t.direct {
+		t.tmux(context.Background(), "kill-session", "-t", t.tmuxSession).Run()
+	}
+	t.stopTapUnlocked()
+
+	return nil
+}
+
+// Restart closes and restarts the terminal. The given options are applied on
+// top of the current configuration, so Restart(ctx) reruns the same command and
+// Restart(ctx, WithShell(cmd)) switches to a new one. Invalid options leave the
+// running terminal untouched.
+func (t *Terminal) Restart(ctx context.Context, opts ...Option) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cfg := t.config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	t.stopSupervisorUnlocked()
+
+	// Close existing browser and ttyd
+	if t.browser != nil {
+		t.browser.Close()
+		t.browser = nil
+	}
+	if t.cmd != nil && t.cmd.Process != nil {
+		t.cmd.Process.Kill()
+		<-t.exited
+		t.cmd = nil
+	}
+	t.page = nil
+
+	// Kill old tmux session
+	if t.tmuxSession != "" && !t.direct {
+		t.tmux(context.Background(), "kill-session", "-t", t.tmuxSession).Run()
+	}
+	t.stopTapUnlocked()
+
+	// Apply new configuration
+	t.config = cfg
+
+	// Keep the same port - wait for it to be released
+	if err := sleep(ctx, 100*time.Millisecond); err != nil {
+		return err
+	}
+
+	// Generate new tmux session name (use timestamp to ensure uniqueness)
+	t.tmuxSession = fmt.Sprintf("imprint_%d_%d", t.port, time.Now().UnixNano()%100000)
+
+	return t.startUnlocked(ctx)
+}
+
+// Status returns terminal status information.
+func (t *Terminal) Status() (rows, cols int, ready bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	ready = t.page != nil
+	return t.rows, t.cols, ready
+}
+
+// GetTtydUrl returns the URL to access the terminal via web browser.
+func (t *Terminal) GetTtydUrl() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return fmt.Sprintf("http://127.0.0.1:%d", t.port)
+}
+
+// GetTmuxSession returns the tmux session name, or "" in direct mode.
+func (t *Terminal) GetTmuxSession() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.direct {
+		return ""
+	}
+	return t.tmuxSession
+}
@@ -0,0 +1,833 @@
+package terminal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testTerminal    *Terminal
+	testTerminalErr error // Why testTerminal could not start, if it did not
+)
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+
+	testTerminal, testTerminalErr = New(WithShell("/bin/sh"), WithSize(24, 80))
+	if testTerminalErr == nil {
+		testTerminalErr = testTerminal.Start(ctx)
+	}
+	if testTerminalErr == nil {
+		testTerminal.WaitForStable(ctx, 2000, 100)
+	}
+
+	code := m.Run()
+
+	if testTerminal != nil {
+		testTerminal.Close()
+	}
+	os.Exit(code)
+}
+
+// resetTerminal clears the terminal state between tests.
+// ctrl+c cancels any running command; clear removes previous output.
+func resetTerminal(t *testing.T) {
+	t.Helper()
+	ctx := t.Context()
+	if err := testTerminal.SendKey(ctx, "ctrl+c"); err != nil {
+		t.Fatalf("resetTerminal: SendKey(ctrl+c) failed: %v", err)
+	}
+	if err := testTerminal.Type(ctx, "clear"); err != nil {
+		t.Fatalf("resetTerminal: Type(clear) failed: %v", err)
+	}
+	if err := testTerminal.SendKey(ctx, "enter"); err != nil {
+		t.Fatalf("resetTerminal: SendKey(enter) failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 500, 100)
+}
+
+func TestTerminal(t *testing.T) {
+	if testTerminalErr != nil {
+		t.Fatalf("failed to start test terminal: %v", testTerminalErr)
+	}
+
+	tests := []struct {
+		name string
+		fn   func(t *testing.T)
+	}{
+		{"TypeAfterCommandExecution", testTypeAfterCommandExecution},
+		{"TypeUnicode", testTypeUnicode},
+		{"SendKeyAfterSendKey", testSendKeyAfterSendKey},
+		{"SendKeyCharacters", testSendKeyCharacters},
+		{"SendKeyAliases", testSendKeyAliases},
+		{"SendKeyErrors", testSendKeyErrors},
+		{"TypeAfterModifierKey", testTypeAfterModifierKey},
+		{"TypeAfterSendKeys", testTypeAfterSendKeys},
+		{"WaitCancelled", testWaitCancelled},
+		{"ProcessStatusRunning", testProcessStatusRunning},
+		{"MultiModifierKeys", testMultiModifierKeys},
+		{"MouseErrors", testMouseErrors},
+		{"Paste", testPaste},
+		{"TypePaced", testTypePaced},
+		{"SendRaw", testSendRaw},
+		{"HeldKeys", testHeldKeys},
+		{"KeyVocabulary", testKeyVocabulary},
+		{"KeyNotation", testKeyNotation},
+		{"KeyboardProtocol", testKeyboardProtocol},
+		{"Compose", testCompose},
+		{"KeyboardLayout", testKeyboardLayout},
+		{"FocusEvents", testFocusEvents},
+		{"Clipboard", testClipboard},
+		{"ScreenCells", testScreenCells},
+		{"InitialPTYSize", testInitialPTYSize},
+		{"Resize", testResize},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resetTerminal(t)
+			tc.fn(t)
+		})
+	}
+}
+
+// testTypeAfterCommandExecution verifies Type() works after SendKey().
+// Type() must succeed regardless of prior SendKey() calls, since agents
+// commonly alternate between typing text and pressing control keys.
+func testTypeAfterCommandExecution(t *testing.T) {
+	ctx := t.Context()
+	if err := testTerminal.Type(ctx, "echo first"); err != nil {
+		t.Fatalf("Type(echo first) failed: %v", err)
+	}
+	if err := testTerminal.SendKey(ctx, "enter"); err != nil {
+		t.Fatalf("SendKey(enter) failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+
+	if err := testTerminal.Type(ctx, "echo second"); err != nil {
+		t.Fatalf("Type(echo second) failed: %v", err)
+	}
+	if err := testTerminal.SendKey(ctx, "enter"); err != nil {
+		t.Fatalf("SendKey(enter) failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+
+	screen, err := testTerminal.GetText(ctx)
+	if err != nil {
+		t.Fatalf("GetText() failed: %v", err)
+	}
+	if !strings.Contains(screen, "second") {
+		t.Errorf("Second command output not found. Screen:\n%s", screen)
+	}
+}
+
+// testTypeUnicode verifies Type() handles Unicode correctly.
+// The term.input() API must preserve multi-byte characters.
+func testTypeUnicode(t *testing.T) {
+	ctx := t.Context()
+	if err := testTerminal.Type(ctx, "echo '🚀'"); err != nil {
+		t.Fatalf("Type(unicode) failed: %v", err)
+	}
+	if err := testTerminal.SendKey(ctx, "enter"); err != nil {
+		t.Fatalf("SendKey(enter) failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+
+	screen, err := testTerminal.GetText(ctx)
+	if err != nil {
+		t.Fatalf("GetText() failed: %v", err)
+	}
+	if !strings.Contains(screen, "🚀") {
+		t.Errorf("Unicode output not found. Screen:\n%s", screen)
+	}
+}
+
+// testSendKeyAfterSendKey verifies consecutive SendKey() calls work.
+// Each SendKey() must leave xterm.js in a clean state for the next input.
+func testSendKeyAfterSendKey(t *testing.T) {
+	ctx := t.Context()
+	// Type a command, press enter, then use arrow-up to recall it
+	if err := testTerminal.Type(ctx, "echo sendkey_test"); err != nil {
+		t.Fatalf("Type() failed: %v", err)
+	}
+	if err := testTerminal.SendKey(ctx, "enter"); err != nil {
+		t.Fatalf("SendKey(enter) failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+
+	// Arrow up should recall the previous command
+	if err := testTerminal.SendKey(ctx, "up"); err != nil {
+		t.Fatalf("SendKey(up) failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 500, 100)
+
+	// Execute the recalled command
+	if err := testTerminal.SendKey(ctx, "enter"); err != nil {
+		t.Fatalf("SendKey(enter) after up failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+
+	screen, err := testTerminal.GetText(ctx)
+	if err != nil {
+		t.Fatalf("GetText() failed: %v", err)
+	}
+
+	// Should see "sendkey_test" output twice (original + recalled)
+	count := strings.Count(screen, "sendkey_test")
+	if count < 2 {
+		t.Errorf("Expected 'sendkey_test' at least twice, found %d times. Screen:\n%s", count, screen)
+	}
+}
+
+// testSendKeyCharacters verifies SendKey() accepts various character types:
+// special keys, letters, digits, punctuation, and Unicode (including grapheme clusters).
+func testSendKeyCharacters(t *testing.T) {
+	ctx := t.Context()
+	samples := []struct {
+		key     string
+		wantErr bool
+	}{
+		// Special keys (regression)
+		{"enter", false},
+		{"tab", false},
+		{"escape", false},
+		{"up", false},
+		{"down", false},
+		{"backspace", false},
+		{"space", false},
+		// Letters (regression)
+		{"a", false},
+		{"z", false},
+		{"A", false},
+		// Digits (new)
+		{"0", false},
+		{"5", false},
+		{"9", false},
+		// Punctuation (new)
+		{"/", false},
+		{".", false},
+		{",", false},
+		{"+", false},
+		{"[", false},
+		{"]", false},
+		{"-", false},
+		// Unicode (new)
+		{"中", false},
+		{"é", false},
+		// Literal space (should work like "space")
+		{" ", false},
+	}
+
+	for _, s := range samples {
+		err := testTerminal.SendKey(ctx, s.key)
+		if (err != nil) != s.wantErr {
+			t.Errorf("SendKey(%q): got error %v, wantErr %v", s.key, err, s.wantErr)
+		}
+	}
+
+	// Lightning bolt emoji - tests grapheme handling
+	lightning := "⚡"
+	outputSamples := []string{"A", "5", ".", "+", "中", "é", lightning}
+	for _, key := range outputSamples {
+		resetTerminal(t)
+		if err := testTerminal.Type(ctx, "printf '%s\\n' "); err != nil {
+			t.Fatalf("Type(printf) failed: %v", err)
+		}
+		if err := testTerminal.SendKey(ctx, key); err != nil {
+			t.Fatalf("SendKey(%q) failed: %v", key, err)
+		}
+		if err := testTerminal.SendKey(ctx, "enter"); err != nil {
+			t.Fatalf("SendKey(enter) failed: %v", err)
+		}
+		testTerminal.WaitForStable(ctx, 1000, 100)
+		assertOutputLine(t, key)
+	}
+}
+
+// testSendKeyAliases verifies literal control characters behave like their named keys.
+func testSendKeyAliases(t *testing.T) {
+	ctx := t.Context()
+	if err := testTerminal.SendKey(ctx, "\t"); err != nil {
+		t.Fatalf("SendKey(\\t) failed: %v", err)
+	}
+
+	if err := testTerminal.Type(ctx, "printf '%s\\n' '"); err != nil {
+		t.Fatalf("Type(printf) failed: %v", err)
+	}
+	if err := testTerminal.SendKey(ctx, "A"); err != nil {
+		t.Fatalf("SendKey(A) failed: %v", err)
+	}
+	if err := testTerminal.SendKey(ctx, " "); err != nil {
+		t.Fatalf("SendKey(space) failed: %v", err)
+	}
+	if err := testTerminal.SendKey(ctx, "B"); err != nil {
+		t.Fatalf("SendKey(B) failed: %v", err)
+	}
+	if err := testTerminal.SendKey(ctx, "'"); err != nil {
+		t.Fatalf("SendKey(') failed: %v", err)
+	}
+	if err := testTerminal.SendKey(ctx, "\n"); err != nil {
+		t.Fatalf("SendKey(\\n) failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+	assertOutputLine(t, "A B")
+}
+
+// testSendKeyErrors verifies SendKey() returns errors for invalid input.
+// Tests empty strings, unknown keys, malformed modifiers, and non-printable characters.
+func testSendKeyErrors(t *testing.T) {
+	ctx := t.Context()
+	errorCases := []struct {
+		key  string
+		desc string
+	}{
+		{"", "empty string"},
+		{"foobar", "unknown key name"},
+		{"ctrl+", "incomplete modifier (missing key)"},
+		{"++a", "malformed (multiple + at start)"},
+		{"ctrl+ctrl+a", "duplicate modifier"},
+		{"ctrl+hyper+a", "unknown modifier in combination"},
+		{"\x00", "non-printable (null)"},
+		{"\x1b", "non-printable (escape byte)"},
+		{"ab", "multiple graphemes"},
+	}
+
+	for _, tc := range errorCases {
+		err := testTerminal.SendKey(ctx, tc.key)
+		if err == nil {
+			t.Errorf("SendKey(%q) [%s]: expected error, got nil", tc.key, tc.desc)
+		}
+	}
+}
+
+func assertOutputLine(t *testing.T, expected string) {
+	t.Helper()
+
+	screen, err := testTerminal.GetText(t.Context())
+	if err != nil {
+		t.Fatalf("GetText() failed: %v", err)
+	}
+
+	for _, line := range strings.Split(screen, "\n") {
+		if line == expected {
+			return
+		}
+	}
+
+	t.Errorf("Expected output line %q not found. Screen:\n%s", expected, screen)
+}
+
+// testTypeAfterModifierKey verifies Type() works after modifier key combinations.
+// Modifier keys (ctrl, alt, shift) use a different code path than simple keys.
+func testTypeAfterModifierKey(t *testing.T) {
+	ctx := t.Context()
+	// Start typing a command
+	if err := testTerminal.Type(ctx, "echo modifier_test"); err != nil {
+		t.Fatalf("Type() failed: %v", err)
+	}
+
+	// Use ctrl+c to cancel (modifier key combination)
+	if err := testTerminal.SendKey(ctx, "ctrl+c"); err != nil {
+		t.Fatalf("SendKey(ctrl+c) failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 500, 100)
+
+	// Type() must still work after the modifier key
+	if err := testTerminal.Type(ctx, "echo after_ctrl"); err != nil {
+		t.Fatalf("Type() after ctrl+c failed: %v", err)
+	}
+	if err := testTerminal.SendKey(ctx, "enter"); err != nil {
+		t.Fatalf("SendKey(enter) failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+
+	screen, err := testTerminal.GetText(ctx)
+	if err != nil {
+		t.Fatalf("GetText() failed: %v", err)
+	}
+	if !strings.Contains(screen, "after_ctrl") {
+		t.Errorf("Output after modifier key not found. Screen:\n%s", screen)
+	}
+}
+
+// testTypeAfterSendKeys verifies Type() works after batch key operations.
+// SendKeys() processes multiple keys in sequence with a single lock acquisition.
+func testTypeAfterSendKeys(t *testing.T) {
+	ctx := t.Context()
+	// Use SendKeys to type and execute a command
+	keys := []string{"e", "c", "h", "o", "space", "b", "a", "t", "c", "h", "enter"}
+	if err := testTerminal.SendKeys(ctx, keys); err != nil {
+		t.Fatalf("SendKeys() failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+
+	// Type() must work after the batch operation
+	if err := testTerminal.Type(ctx, "echo after_batch"); err != nil {
+		t.Fatalf("Type() after SendKeys() failed: %v", err)
+	}
+	if err := testTerminal.SendKey(ctx, "enter"); err != nil {
+		t.Fatalf("SendKey(enter) failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+
+	screen, err := testTerminal.GetText(ctx)
+	if err != nil {
+		t.Fatalf("GetText() failed: %v", err)
+	}
+	if !strings.Contains(screen, "after_batch") {
+		t.Errorf("Output after batch keys not found. Screen:\n%s", screen)
+	}
+}
+
+// testWaitCancelled verifies waits return promptly with the context's error
+// when the caller cancels, instead of polling until their own timeout.
+func testWaitCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(t.Context(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, found, err := testTerminal.WaitForText(ctx, "text_that_never_appears", 5000)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitForText: expected context.DeadlineExceeded, got %v", err)
+	}
+	if found {
+		t.Errorf("WaitForText: expected found=false after cancellation")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("WaitForText took %v after cancellation, expected prompt return", elapsed)
+	}
+
+	ctx, cancel = context.WithCancel(t.Context())
+	cancel()
+	if _, stable, err := testTerminal.WaitForStable(ctx, 5000, 4000); err == nil || stable {
+		t.Errorf("WaitForStable with cancelled context: got stable=%t err=%v, want error", stable, err)
+	}
+}
+
+// testProcessStatusRunning verifies the shell under test reports as running.
+func testProcessStatusRunning(t *testing.T) {
+	status, err := testTerminal.ProcessStatus(t.Context())
+	if err != nil {
+		t.Fatalf("ProcessStatus() failed: %v", err)
+	}
+	if !status.Running {
+		t.Errorf("expected shell to be running, got %+v", status)
+	}
+	if status.PID <= 0 {
+		t.Errorf("expected a positive PID, got %d", status.PID)
+	}
+}
+
+// testInitialPTYSize verifies the PTY starts at the size passed to New.
+func testInitialPTYSize(t *testing.T) {
+	rows, cols, err := testTerminal.PTYSize(t.Context())
+	if err != nil {
+		t.Fatalf("PTYSize() failed: %v", err)
+	}
+	if rows != 24 || cols != 80 {
+		t.Errorf("expected PTY size 24x80, got %dx%d", rows, cols)
+	}
+}
+
+// testResize verifies Resize reaches the app's PTY, then restores the original size.
+func testResize(t *testing.T) {
+	ctx := t.Context()
+
+	if err := testTerminal.Resize(ctx, 30, 100); err != nil {
+		t.Fatalf("Resize() failed: %v", err)
+	}
+	defer testTerminal.Resize(context.Background(), 24, 80)
+
+	rows, cols, err := testTerminal.PTYSize(ctx)
+	if err != nil {
+		t.Fatalf("PTYSize() failed: %v", err)
+	}
+	if rows != 30 || cols != 100 {
+		t.Errorf("expected PTY size 30x100, got %dx%d", rows, cols)
+	}
+}
+
+// testMultiModifierKeys verifies combinations of several modifiers reach the
+// app with every modifier applied, using cat -v to make them visible.
+func testMultiModifierKeys(t *testing.T) {
+	ctx := t.Context()
+	if err := testTerminal.Type(ctx, "cat -v"); err != nil {
+		t.Fatalf("Type() failed: %v", err)
+	}
+	if err := testTerminal.SendKeys(ctx, []string{"enter", "ctrl+alt+a", "shift+alt+b", "enter"}); err != nil {
+		t.Fatalf("SendKeys() failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+	defer testTerminal.SendKey(context.Background(), "ctrl+c")
+
+	assertOutputLine(t, "^[^A^[B")
+}
+
+// testMouseErrors verifies mouse input rejects cells outside the screen and unknown buttons.
+func testMouseErrors(t *testing.T) {
+	ctx := t.Context()
+	if err := testTerminal.MouseClick(ctx, 24, 0, MouseLeft, 1); err == nil {
+		t.Error("MouseClick() below the screen: expected error, got nil")
+	}
+	if err := testTerminal.MouseClick(ctx, 0, 0, MouseButton("fourth"), 1); err == nil {
+		t.Error("MouseClick() with unknown button: expected error, got nil")
+	}
+	if err := testTerminal.MouseDrag(ctx, 0, 0, 0, 80, MouseLeft); err == nil {
+		t.Error("MouseDrag() past the last column: expected error, got nil")
+	}
+	if err := testTerminal.MouseScroll(ctx, 0, 0, 0); err == nil {
+		t.Error("MouseScroll() by zero lines: expected error, got nil")
+	}
+}
+
+// testPaste verifies pasted text reaches the shell, with line breaks sent as
+// carriage returns.
+func testPaste(t *testing.T) {
+	ctx := t.Context()
+	if _, err := testTerminal.Paste(ctx, "echo paste_test\n"); err != nil {
+		t.Fatalf("Paste() failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+	assertOutputLine(t, "paste_test")
+}
+
+// testTypePaced verifies paced typing delivers every grapheme in order, and
+// that the terminal can be read between keys.
+func testTypePaced(t *testing.T) {
+	ctx := t.Context()
+	pace := Pace{Delay: 5 * time.Millisecond, Jitter: 5 * time.Millisecond}
+	if err := testTerminal.TypePaced(ctx, "echo paced_✓", pace); err != nil {
+		t.Fatalf("TypePaced() failed: %v", err)
+	}
+	if err := testTerminal.SendKeysPaced(ctx, []string{"enter"}, pace); err != nil {
+		t.Fatalf("SendKeysPaced() failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+	assertOutputLine(t, "paced_✓")
+
+	// Reads go on during the pauses between keys
+	done := make(chan error, 1)
+	go func() {
+		done <- testTerminal.TypePaced(ctx, "slow", Pace{Delay: 300 * time.Millisecond})
+	}()
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	if _, err := testTerminal.GetText(ctx); err != nil {
+		t.Errorf("GetText() during TypePaced failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("GetText() during TypePaced took %v, want it not to wait for the typing", elapsed)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("TypePaced(slow) failed: %v", err)
+	}
+}
+
+// testSendRaw verifies raw bytes reach the shell unchanged, including a
+// carriage return that no printable key would send.
+func testSendRaw(t *testing.T) {
+	ctx := t.Context()
+	if err := testTerminal.SendRaw(ctx, []byte("echo raw_test\r")); err != nil {
+		t.Fatalf("SendRaw() failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+	assertOutputLine(t, "raw_test")
+
+	if err := testTerminal.SendRaw(ctx, nil); err == nil {
+		t.Error("SendRaw(nil): expected error, got nil")
+	}
+}
+
+// testHeldKeys verifies a modifier held by KeyDown applies to later keys, and
+// that keys are released in any order.
+func testHeldKeys(t *testing.T) {
+	ctx := t.Context()
+	if err := testTerminal.Type(ctx, "echo held_"); err != nil {
+		t.Fatalf("Type() failed: %v", err)
+	}
+	for _, key := range []string{"shift", "k"} {
+		if err := testTerminal.KeyDown(ctx, key); err != nil {
+			t.Fatalf("KeyDown(%q) failed: %v", key, err)
+		}
+	}
+	if err := testTerminal.KeyDown(ctx, "shift"); err == nil {
+		t.Error("KeyDown(shift) while held: expected error, got nil")
+	}
+	for _, key := range []string{"shift", "k"} {
+		if err := testTerminal.KeyUp(ctx, key); err != nil {
+			t.Fatalf("KeyUp(%q) failed: %v", key, err)
+		}
+	}
+	if held := testTerminal.HeldKeys(); len(held) != 0 {
+		t.Errorf("HeldKeys() = %v, want none", held)
+	}
+	if err := testTerminal.KeyUp(ctx, "k"); err == nil {
+		t.Error("KeyUp(k) when not held: expected error, got nil")
+	}
+
+	// A modifier KeyDown holds is used by HoldKey and stays held after it
+	if err := testTerminal.KeyDown(ctx, "shift"); err != nil {
+		t.Fatalf("KeyDown(shift) failed: %v", err)
+	}
+	if _, err := testTerminal.HoldKey(ctx, "shift+x", 50*time.Millisecond, KeyRepeat{}); err != nil {
+		t.Fatalf("HoldKey(shift+x) with shift held failed: %v", err)
+	}
+	if held := testTerminal.HeldKeys(); len(held) != 1 {
+		t.Errorf("HeldKeys() after HoldKey(shift+x) = %v, want shift alone", held)
+	}
+	if err := testTerminal.KeyUp(ctx, "shift"); err != nil {
+		t.Fatalf("KeyUp(shift) failed: %v", err)
+	}
+
+	// Reads go on while a key is held
+	done := make(chan error, 1)
+	go func() {
+		_, err := testTerminal.HoldKey(ctx, "ctrl", time.Second, KeyRepeat{})
+		done <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	if _, err := testTerminal.GetText(ctx); err != nil {
+		t.Errorf("GetText() during HoldKey failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("GetText() during HoldKey took %v, want it not to wait for the hold", elapsed)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("HoldKey(ctrl) failed: %v", err)
+	}
+
+	if _, err := testTerminal.HoldKey(ctx, "enter", 50*time.Millisecond, KeyRepeat{}); err != nil {
+		t.Fatalf("HoldKey() failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+	assertOutputLine(t, "held_KX")
+}
+
+// testKeyVocabulary verifies control characters, keypad keys and shifted
+// characters with modifiers reach the app as xterm would send them.
+func testKeyVocabulary(t *testing.T) {
+	ctx := t.Context()
+	if err := testTerminal.Type(ctx, "cat -v"); err != nil {
+		t.Fatalf("Type() failed: %v", err)
+	}
+	keys := []string{"enter", "ctrl+/", "ctrl+@", "ctrl+[", "kp7", "alt+A", "enter"}
+	if err := testTerminal.SendKeys(ctx, keys); err != nil {
+		t.Fatalf("SendKeys() failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+	assertOutputLine(t, "^_^@^[7^[A")
+}
+
+// testKeyNotation verifies keys parsed from notation send.
+func testKeyNotation(t *testing.T) {
+	ctx := t.Context()
+	keys, err := ParseKeyNotation("echo notation_ok<CR>", NotationVim)
+	if err != nil {
+		t.Fatalf("ParseKeyNotation() failed: %v", err)
+	}
+	if err := testTerminal.SendKeys(ctx, keys); err != nil {
+		t.Fatalf("SendKeys() failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+	assertOutputLine(t, "notation_ok")
+}
+
+// testKeyboardProtocol verifies the protocol an app enables is detected from
+// its output, that its query for it is answered, that keys are encoded for
+// it, and that SetKeyboardProtocol overrides it.
+func testKeyboardProtocol(t *testing.T) {
+	ctx := t.Context()
+	if err := testTerminal.Type(ctx, `printf '\033[>1u\033[?u'; head -n1 | cat -v; printf '\033[<u'`); err != nil {
+		t.Fatalf("Type() failed: %v", err)
+	}
+	if err := testTerminal.SendKey(ctx, "enter"); err != nil {
+		t.Fatalf("SendKey() failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+	if p, forced, err := testTerminal.KeyboardProtocol(ctx); err != nil || forced || p.KittyFlags != KittyDisambiguate {
+		t.Errorf("KeyboardProtocol() = %v, %t, %v; want kitty flags 1 from the app", p, forced, err)
+	}
+	if err := testTerminal.SendKeys(ctx, []string{"ctrl+shift+enter", "enter"}); err != nil {
+		t.Fatalf("SendKeys() failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+	assertOutputLine(t, "^[[?1u^[[13;6u") // The answer to the app's query, then the key
+	if p, _, err := testTerminal.KeyboardProtocol(ctx); err != nil || p != (KeyboardProtocol{}) {
+		t.Errorf("KeyboardProtocol() after pop = %v, %v; want legacy", p, err)
+	}
+
+	if err := testTerminal.SetKeyboardProtocol(&KeyboardProtocol{ModifyOtherKeys: 2}); err != nil {
+		t.Fatalf("SetKeyboardProtocol() failed: %v", err)
+	}
+	defer testTerminal.SetKeyboardProtocol(nil)
+	if p, forced, err := testTerminal.KeyboardProtocol(ctx); err != nil || !forced || p.ModifyOtherKeys != 2 {
+		t.Errorf("KeyboardProtocol() = %v, %t, %v; want forced modifyOtherKeys 2", p, forced, err)
+	}
+	if err := testTerminal.SetKeyboardProtocol(&KeyboardProtocol{KittyFlags: 64}); err == nil {
+		t.Error("SetKeyboardProtocol(flags 64): expected error, got nil")
+	}
+}
+
+// testCompose verifies text entered through IME composition is committed
+// once, and that a cancelled composition enters nothing.
+func testCompose(t *testing.T) {
+	ctx := t.Context()
+	if err := testTerminal.Type(ctx, "echo ime_"); err != nil {
+		t.Fatalf("Type() failed: %v", err)
+	}
+	composition := Composition{Preedit: []string{"n", "に", "にほ", "日本"}, Text: "日本"}
+	if err := testTerminal.Compose(ctx, composition, Pace{}); err != nil {
+		t.Fatalf("Compose() failed: %v", err)
+	}
+	if err := testTerminal.Compose(ctx, Composition{Preedit: []string{"x"}}, Pace{}); err != nil {
+		t.Fatalf("Compose() to cancel failed: %v", err)
+	}
+	if err := testTerminal.Compose(ctx, Composition{Text: "語"}, Pace{}); err != nil {
+		t.Fatalf("Compose() without preedit failed: %v", err)
+	}
+	if err := testTerminal.SendKey(ctx, "enter"); err != nil {
+		t.Fatalf("SendKey() failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+	assertOutputLine(t, "ime_日本語")
+
+	if err := testTerminal.Compose(ctx, Composition{}, Pace{}); err == nil {
+		t.Error("Compose(empty): expected error, got nil")
+	}
+}
+
+// testKeyboardLayout verifies the layout defaults to DefaultKeyboardLayout.
+func testKeyboardLayout(t *testing.T) {
+	if layout := testTerminal.KeyboardLayout(); layout != DefaultKeyboardLayout {
+		t.Errorf("KeyboardLayout() = %q, want %q", layout, DefaultKeyboardLayout)
+	}
+}
+
+// testFocusEvents verifies Blur and Focus send focus events to an app with
+// focus reporting on, and that the mode is detected as the app sets it.
+func testFocusEvents(t *testing.T) {
+	ctx := t.Context()
+	if err := testTerminal.Type(ctx, `printf '\033[?1004h'; cat -v; printf '\033[?1004l'`); err != nil {
+		t.Fatalf("Type() failed: %v", err)
+	}
+	if err := testTerminal.SendKeys(ctx, []string{"enter", "enter"}); err != nil {
+		t.Fatalf("SendKeys() failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+	if on, err := testTerminal.FocusReporting(ctx); err != nil || !on {
+		t.Errorf("FocusReporting() = %t, %v; want true", on, err)
+	}
+
+	if err := testTerminal.Blur(ctx); err != nil {
+		t.Fatalf("Blur() failed: %v", err)
+	}
+	if testTerminal.Focused() {
+		t.Error("Focused() = true after Blur()")
+	}
+	if err := testTerminal.Blur(ctx); err != nil {
+		t.Fatalf("Blur() while blurred failed: %v", err)
+	}
+	if err := testTerminal.Focus(ctx); err != nil {
+		t.Fatalf("Focus() failed: %v", err)
+	}
+	if !testTerminal.Focused() {
+		t.Error("Focused() = false after Focus()")
+	}
+	if err := testTerminal.SendKeys(ctx, []string{"enter", "ctrl+d"}); err != nil {
+		t.Fatalf("SendKeys() failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+	assertOutputLine(t, "^[[O^[[I")
+	if on, err := testTerminal.FocusReporting(ctx); err != nil || on {
+		t.Errorf("FocusReporting() after the app turned it off = %t, %v; want false", on, err)
+	}
+}
+
+// testClipboard verifies the app's OSC 52 writes are recorded, that its paste
+// queries get SetClipboard's text back, and that SetClipboard("") clears it.
+func testClipboard(t *testing.T) {
+	ctx := t.Context()
+	if err := testTerminal.Type(ctx, `printf '\033]52;c;d29ybGQ=\a'`); err != nil {
+		t.Fatalf("Type() failed: %v", err)
+	}
+	if err := testTerminal.SendKey(ctx, "enter"); err != nil {
+		t.Fatalf("SendKey() failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+	clipboard, err := testTerminal.Clipboard(ctx)
+	if err != nil {
+		t.Fatalf("Clipboard() failed: %v", err)
+	}
+	if clipboard.Text != "world" || len(clipboard.History) == 0 || clipboard.History[len(clipboard.History)-1] != (ClipboardWrite{"c", "world"}) {
+		t.Errorf("Clipboard() = %+v, want the app's write of \"world\"", clipboard)
+	}
+
+	if err := testTerminal.SetClipboard(ctx, "wörld"); err != nil {
+		t.Fatalf("SetClipboard() failed: %v", err)
+	}
+	if err := testTerminal.Type(ctx, `stty -echo; printf '\033]52;c;?\a'; cat -v; stty echo`); err != nil {
+		t.Fatalf("Type() failed: %v", err)
+	}
+	if err := testTerminal.SendKeys(ctx, []string{"enter", "enter", "ctrl+d"}); err != nil {
+		t.Fatalf("SendKeys() failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+	assertOutputLine(t, "^[]52;c;d8O2cmxk^G")
+
+	if err := testTerminal.SetClipboard(ctx, ""); err != nil {
+		t.Fatalf("SetClipboard(\"\") failed: %v", err)
+	}
+	if clipboard, err := testTerminal.Clipboard(ctx); err != nil || clipboard.Text != "" {
+		t.Errorf("Clipboard() after clearing = %+v, %v; want empty text", clipboard, err)
+	}
+}
+
+// testScreenCells verifies GetCells reports colors, attributes and wide
+// characters, grouping cells with the same style into runs.
+func testScreenCells(t *testing.T) {
+	ctx := t.Context()
+	if err := testTerminal.Type(ctx, `printf '\033[1;31mred\033[0m \033[38;2;1;2;3;44;7mrgb\033[0m 日本\n'`); err != nil {
+		t.Fatalf("Type() failed: %v", err)
+	}
+	if err := testTerminal.SendKey(ctx, "enter"); err != nil {
+		t.Fatalf("SendKey() failed: %v", err)
+	}
+	testTerminal.WaitForStable(ctx, 1000, 100)
+
+	cells, err := testTerminal.GetCells(ctx)
+	if err != nil {
+		t.Fatalf("GetCells() failed: %v", err)
+	}
+	if cells.Rows != 24 || cells.Cols != 80 || len(cells.Lines) != cells.Rows {
+		t.Fatalf("GetCells() = %dx%d with %d lines, want 24x80 with 24", cells.Rows, cells.Cols, len(cells.Lines))
+	}
+
+	// The output line is the one starting with the bold red run, not the
+	// command line, which has the same text in the default style
+	for _, runs := range cells.Lines {
+		if len(runs) < 5 || runs[0].Text != "red" {
+			continue
+		}
+		want := []CellRun{
+			{Col: 0, Cells: 3, Width: 1, Text: "red", CellStyle: CellStyle{FG: Color{ColorPalette, 1}, Bold: true}},
+			{Col: 3, Cells: 1, Width: 1, Text: " "},
+			{Col: 4, Cells: 3, Width: 1, Text: "rgb", CellStyle: CellStyle{FG: Color{ColorRGB, 0x010203}, BG: Color{ColorPalette, 4}, Inverse: true}},
+			{Col: 7, Cells: 1, Width: 1, Text: " "},
+			{Col: 8, Cells: 2, Width: 2, Text: "日本"},
+		}
+		if !slices.Equal(runs[:5], want) {
+			t.Errorf("runs = %+v, want %+v", runs[:5], want)
+		}
+		if got := runs[4].Columns(); got != 4 {
+			t.Errorf("Columns() of the wide run = %d, want 4", got)
+		}
+		return
+	}
+	t.Errorf("no row starts with a bold red run: %+v", cells.Lines)
+}
@@ -0,0 +1,157 @@
+package terminal
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultTmuxSocket is the name of the tmux server socket imprint uses unless
+// WithTmuxSocket overrides it. Keeping imprint off the user's default server
+// means ~/.tmux.conf never applies to the terminal under test.
+const DefaultTmuxSocket = "imprint"
+
+// tmuxConfig replaces ~/.tmux.conf on imprint's tmux server, so the app under
+// test sees a plain terminal with no status line, prefix key or escape delay.
+var tmuxConfig = [][]string{
+	{"set-option", "-g", "status", "off"},
+	{"set-option", "-g", "prefix", "None"},
+	{"set-option", "-g", "prefix2", "None"},
+	{"set-option", "-g", "escape-time", "0"},
+	{"set-option", "-g", "mouse", "off"},
+	// Pass focus changes on to apps that turn on focus reporting
+	{"set-option", "-g", "focus-events", "on"},
+	// Leave OSC 52 to imprint, which keeps a clipboard per terminal: tmux
+	// would share one paste buffer stack among every session on the server
+	{"set-option", "-g", "set-clipboard", "off"},
+	{"set-option", "-g", "default-terminal", "screen-256color"},
+	// Tell tmux the page's xterm.js takes 24-bit color, so colors the app
+	// sets reach the screen (and GetCells) as given instead of the nearest
+	// palette color
+	{"set-option", "-s", "terminal-overrides", ",*:Tc"},
+	{"set-option", "-g", "history-limit", "10000"},
+	{"set-option", "-wg", "automatic-rename", "off"},
+	// Keep the pane around after the command exits so its exit status can be
+	// inspected via ProcessStatus instead of tmux closing the session.
+	{"set-option", "-wg", "remain-on-exit", "on"},
+}
+
+// tmuxArgs prefixes args with the flags that select imprint's tmux server.
+// Socket names containing a slash are paths (-S); anything else is a name
+// in tmux's socket directory (-L).
+func (c *config) tmuxArgs(args ...string) []string {
+	flag := "-L"
+	if strings.Contains(c.tmuxSocket, "/") {
+		flag = "-S"
+	}
+	return append([]string{flag, c.tmuxSocket}, args...)
+}
+
+// tmux returns a tmux command addressed to imprint's tmux server.
+// Caller must hold at least the read lock.
+func (t *Terminal) tmux(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "tmux", t.tmuxArgs(args...)...)
+}
+
+// tmuxServerArgs returns the tmux arguments that start imprint's server with
+// the built-in config, if it is not running yet, before running command.
+func (c *config) tmuxServerArgs(command ...string) []string {
+	// -f /dev/null skips ~/.tmux.conf
+	args := append([]string{"-f", "/dev/null"}, c.tmuxArgs("start-server")...)
+	for _, option := range tmuxConfig {
+		args = append(args, ";")
+		args = append(args, option...)
+	}
+	args = append(args, ";")
+	return append(args, command...)
+}
+
+// startSessionUnlocked creates the detached tmux session running the command
+// at exactly the configured size. With window-size manual the window keeps that
+// size whatever size the attached clients are, so the app only ever sees the
+// sizes set by Start and Resize. Caller must hold the lock.
+func (t *Terminal) startSessionUnlocked(ctx context.Context) error {
+	session := []string{"new-session", "-d", "-s", t.tmuxSession,
+		"-x", strconv.Itoa(t.cols), "-y", strconv.Itoa(t.rows)}
+
+	// Working directory and environment for the command under test
+	if t.dir != "" {
+		session = append(session, "-c", escapeTmuxArg(t.dir))
+	}
+	for _, key := range slices.Sorted(maps.Keys(t.env)) {
+		session = append(session, "-e", escapeTmuxArg(key+"="+t.env[key]))
+	}
+
+	session = append(session, t.tmuxCommandArgs()...)
+
+	// Setting window-size while the server has no sessions crashes tmux 3.3,
+	// so it is applied after new-session rather than with tmuxConfig
+	session = append(session, ";", "set-option", "-g", "window-size", "manual")
+
+	// Copy the pane's output, from its first byte, to the output tap
+	if t.tap != nil {
+		session = append(session, ";", "pipe-pane", "-O", "-t", t.tmuxSession,
+			"exec cat >> "+shellQuote(t.tap.path()))
+	}
+
+	out, err := exec.CommandContext(ctx, "tmux", t.tmuxServerArgs(session...)...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to start tmux session: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// tmuxCommandArgs returns the tmux new-session arguments that run the
+// configured command.
+func (c *config) tmuxCommandArgs() []string {
+	// tmux runs a lone argument through sh -c, so wrap it in an exec that
+	// passes it through verbatim. Two or more arguments are exec'd directly.
+	argv := c.commandArgv()
+	if len(argv) == 1 {
+		argv = []string{"sh", "-c", `exec "$0"`, argv[0]}
+	}
+	escaped := make([]string, len(argv))
+	for i, arg := range argv {
+		escaped[i] = escapeTmuxArg(arg)
+	}
+	return escaped
+}
+
+// escapeTmuxArg protects a trailing semicolon, which tmux would otherwise
+// take as a command separator, by escaping it as \;.
+func escapeTmuxArg(arg string) string {
+	if strings.HasSuffix(arg, ";") {
+		return arg[:len(arg)-1] + `\;`
+	}
+	return arg
+}
+
+// AttachCommand returns the shell command that attaches to the terminal's
+// tmux session, for watching or driving it from a real terminal. It returns ""
+// in direct mode, where there is no tmux session to attach to.
+func (t *Terminal) AttachCommand() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.direct {
+		return ""
+	}
+
+	args := t.tmuxArgs("attach", "-t", t.tmuxSession)
+	for i, arg := range args {
+		args[i] = shellQuote(arg)
+	}
+	return "tmux " + strings.Join(args, " ")
+}
+
+// shellQuote quotes s for a POSIX shell if it contains special characters.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./:@%+=") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}